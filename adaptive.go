@@ -0,0 +1,191 @@
+package batcher
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// LimitAlgorithm computes a new concurrency limit each time a token of an adaptive control is released.
+type LimitAlgorithm interface {
+	// Update returns the new limit given the current limit, the latency between acquire and release,
+	// the number of tokens in flight when the token was released, and whether the guarded work failed.
+	Update(limit float64, latency time.Duration, inflight int, failed bool) float64
+}
+
+// aimdLimitAlgorithm is an additive increase, multiplicative decrease LimitAlgorithm.
+type aimdLimitAlgorithm struct {
+	latencyThreshold time.Duration
+	backoff          float64
+}
+
+// NewAIMDLimitAlgorithm creates a new additive increase, multiplicative decrease LimitAlgorithm.
+// The limit grows by one per limit successes while the control is at least half used,
+// and is multiplied by backoff on failure or when the latency is over latencyThreshold.
+// A zero latencyThreshold only backs off on failure.
+func NewAIMDLimitAlgorithm(latencyThreshold time.Duration, backoff float64) LimitAlgorithm {
+	return &aimdLimitAlgorithm{
+		latencyThreshold: latencyThreshold,
+		backoff:          backoff,
+	}
+}
+
+// Update implements the LimitAlgorithm interface.
+func (a *aimdLimitAlgorithm) Update(limit float64, latency time.Duration, inflight int, failed bool) float64 {
+	if failed || (a.latencyThreshold > 0 && latency > a.latencyThreshold) {
+		return limit * a.backoff
+	}
+	if float64(inflight)*2 >= limit {
+		return limit + 1/limit
+	}
+	return limit
+}
+
+// gradientLimitAlgorithm is a LimitAlgorithm that compares the latency with the lowest latency seen.
+type gradientLimitAlgorithm struct {
+	tolerance  float64
+	smoothing  float64
+	minLatency time.Duration
+}
+
+// NewGradientLimitAlgorithm creates a new LimitAlgorithm that follows the gradient between the lowest latency seen
+// and the current one, like TCP Vegas. While the latency stays within tolerance times the lowest one the limit
+// grows by its square root, above it the limit shrinks in proportion. Smoothing is the weight of each update.
+func NewGradientLimitAlgorithm(tolerance float64, smoothing float64) LimitAlgorithm {
+	return &gradientLimitAlgorithm{
+		tolerance: tolerance,
+		smoothing: smoothing,
+	}
+}
+
+// Update implements the LimitAlgorithm interface.
+func (g *gradientLimitAlgorithm) Update(limit float64, latency time.Duration, inflight int, failed bool) float64 {
+	if failed {
+		return limit * 0.9
+	}
+	if latency <= 0 {
+		return limit
+	}
+	if g.minLatency == 0 || latency < g.minLatency {
+		g.minLatency = latency
+	}
+
+	gradient := math.Max(0.5, math.Min(1, g.tolerance*float64(g.minLatency)/float64(latency)))
+	next := limit*gradient + math.Sqrt(limit)
+	return limit*(1-g.smoothing) + next*g.smoothing
+}
+
+// adaptiveConcurrencyControl is a ConcurrencyControl whose limit follows the latency and errors of the guarded work.
+type adaptiveConcurrencyControl struct {
+	mu        sync.Mutex
+	clock     clock.PassiveClock
+	algorithm LimitAlgorithm
+	sem       *semaphore
+
+	limit    float64
+	minLimit int
+	maxLimit int
+}
+
+// NewAdaptiveConcurrencyControl creates a new adaptiveConcurrencyControl that starts at initial
+// and keeps its limit within minLimit and maxLimit. It uses an AIMD algorithm that only backs off
+// on errors unless another one is set with WithAdaptiveConcurrencyControlAlgorithm.
+func NewAdaptiveConcurrencyControl(initial, minLimit, maxLimit int, option ...adaptiveConcurrencyControlOption) ConcurrencyControl {
+	cc := &adaptiveConcurrencyControl{
+		clock:     clock.RealClock{},
+		algorithm: NewAIMDLimitAlgorithm(0, 0.9),
+		minLimit:  minLimit,
+		maxLimit:  maxLimit,
+	}
+
+	for _, opt := range option {
+		opt(cc)
+	}
+
+	cc.limit = cc.bound(float64(initial))
+	cc.sem = newSemaphore(int(cc.limit))
+
+	return cc
+}
+
+// Acquire acquires a concurrency token.
+func (a *adaptiveConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	if err := a.sem.acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return a.token(), nil
+}
+
+// TryAcquire acquires a concurrency token if one is available right now.
+func (a *adaptiveConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	if !a.sem.tryAcquire(1) {
+		return nil, false
+	}
+	return a.token(), true
+}
+
+// Available returns how many concurrency tokens could be acquired right now.
+func (a *adaptiveConcurrencyControl) Available() int {
+	return a.sem.available()
+}
+
+// Released returns a channel that is closed the next time a token is released or the limit changes.
+func (a *adaptiveConcurrencyControl) Released() <-chan struct{} {
+	return a.sem.releasedSignal()
+}
+
+// token creates a token that measures the latency until it is released.
+func (a *adaptiveConcurrencyControl) token() ConcurrencyToken {
+	acquiredAt := a.clock.Now()
+	return NewFeedbackConcurrencyToken(func(err error) {
+		a.release(a.clock.Since(acquiredAt), err != nil)
+	})
+}
+
+// Limit returns the current concurrency limit.
+func (a *adaptiveConcurrencyControl) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return int(a.limit)
+}
+
+// release updates the limit with the outcome of the guarded work and gives the token back.
+func (a *adaptiveConcurrencyControl) release(latency time.Duration, failed bool) {
+	a.sem.mu.Lock()
+	inflight := a.sem.used
+	a.sem.mu.Unlock()
+
+	a.mu.Lock()
+	a.limit = a.bound(a.algorithm.Update(a.limit, latency, inflight, failed))
+	limit := int(a.limit)
+	a.mu.Unlock()
+
+	a.sem.setLimit(limit)
+	a.sem.release(1)
+}
+
+// bound keeps the limit within the minimum and maximum limit.
+func (a *adaptiveConcurrencyControl) bound(limit float64) float64 {
+	return math.Max(float64(a.minLimit), math.Min(float64(a.maxLimit), limit))
+}
+
+// adaptiveConcurrencyControlOption is a function that configures an adaptiveConcurrencyControl.
+type adaptiveConcurrencyControlOption func(*adaptiveConcurrencyControl)
+
+// WithAdaptiveConcurrencyControlAlgorithm returns an option that sets the LimitAlgorithm for an adaptiveConcurrencyControl.
+func WithAdaptiveConcurrencyControlAlgorithm(algorithm LimitAlgorithm) adaptiveConcurrencyControlOption {
+	return func(a *adaptiveConcurrencyControl) {
+		a.algorithm = algorithm
+	}
+}
+
+// WithAdaptiveConcurrencyControlClock returns an option that sets the clock for an adaptiveConcurrencyControl.
+func WithAdaptiveConcurrencyControlClock(clock clock.PassiveClock) adaptiveConcurrencyControlOption {
+	return func(a *adaptiveConcurrencyControl) {
+		a.clock = clock
+	}
+}
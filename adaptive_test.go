@@ -0,0 +1,114 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("AIMDLimitAlgorithm", func() {
+	var algorithm LimitAlgorithm
+
+	BeforeEach(func() {
+		algorithm = NewAIMDLimitAlgorithm(time.Second, 0.5)
+	})
+
+	It("should increase limit additively when used", func() {
+		Expect(algorithm.Update(10, time.Millisecond, 10, false)).To(BeNumerically("~", 10.1))
+	})
+
+	It("should keep limit when mostly idle", func() {
+		Expect(algorithm.Update(10, time.Millisecond, 1, false)).To(Equal(10.0))
+	})
+
+	It("should decrease limit multiplicatively on failure or slow latency", func() {
+		Expect(algorithm.Update(10, time.Millisecond, 10, true)).To(Equal(5.0))
+		Expect(algorithm.Update(10, 2*time.Second, 10, false)).To(Equal(5.0))
+	})
+})
+
+var _ = Describe("GradientLimitAlgorithm", func() {
+	var algorithm LimitAlgorithm
+
+	BeforeEach(func() {
+		algorithm = NewGradientLimitAlgorithm(1, 1)
+	})
+
+	It("should grow limit while latency stays at minimum", func() {
+		Expect(algorithm.Update(16, time.Millisecond, 16, false)).To(Equal(20.0))
+	})
+
+	It("should shrink limit when latency grows", func() {
+		algorithm.Update(16, time.Millisecond, 16, false)
+		Expect(algorithm.Update(16, 2*time.Millisecond, 16, false)).To(Equal(12.0))
+	})
+})
+
+var _ = Describe("AdaptiveConcurrencyControl", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakePassiveClock
+		initial   int
+		cc        *adaptiveConcurrencyControl
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		fakeClock = clocktesting.NewFakePassiveClock(time.Now())
+		initial = gofakeit.Number(5, 10)
+		cc = NewAdaptiveConcurrencyControl(initial, 2, initial+1,
+			WithAdaptiveConcurrencyControlClock(fakeClock),
+			WithAdaptiveConcurrencyControlAlgorithm(NewAIMDLimitAlgorithm(time.Second, 0.5)),
+		).(*adaptiveConcurrencyControl)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	It("should decrease limit when released with error", func() {
+		token, err := cc.Acquire(ctx)
+		Expect(err).Should(BeNil())
+
+		releaseToken(token, errors.New("error"))
+		Expect(cc.Limit()).Should(Equal(initial / 2))
+		Expect(cc.sem.limit).Should(Equal(initial / 2))
+		Expect(cc.sem.used).Should(Equal(0))
+	})
+
+	It("should decrease limit when released slowly", func() {
+		token, err := cc.Acquire(ctx)
+		Expect(err).Should(BeNil())
+
+		fakeClock.SetTime(fakeClock.Now().Add(2 * time.Second))
+		token.Release()
+		Expect(cc.Limit()).Should(Equal(initial / 2))
+	})
+
+	It("should keep limit within bounds", func() {
+		for i := 0; i < 10; i++ {
+			token, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+			releaseToken(token, errors.New("error"))
+		}
+		Expect(cc.Limit()).Should(Equal(2))
+
+		for i := 0; i < 1000; i++ {
+			tokens := make([]ConcurrencyToken, cc.Limit())
+			for j := range tokens {
+				tokens[j], _ = cc.Acquire(ctx)
+			}
+			for _, token := range tokens {
+				token.Release()
+			}
+		}
+		Expect(cc.Limit()).Should(Equal(initial + 1))
+	})
+})
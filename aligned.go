@@ -0,0 +1,77 @@
+package batcher
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// AlignedWindowScheduler is a Scheduler that dispatches batches at wall clock boundaries,
+// every period since the zero time shifted by offset, instead of relative to the first request of the batch.
+type AlignedWindowScheduler struct {
+	clock  clock.Clock
+	period time.Duration
+	offset time.Duration
+	jitter time.Duration
+}
+
+// NewAlignedWindowScheduler creates a new AlignedWindowScheduler that dispatches batches every period, shifted by offset.
+// For example a period of a minute and an offset of five seconds dispatches at five seconds past every minute.
+func NewAlignedWindowScheduler(period time.Duration, offset time.Duration, option ...alignedWindowSchedulerOption) Scheduler {
+	s := &AlignedWindowScheduler{
+		clock:  clock.RealClock{},
+		period: period,
+		offset: offset,
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	return s
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (a *AlignedWindowScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	now := a.clock.Now()
+	timer := a.clock.NewTimer(a.next(now).Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-batch.Dispatch():
+		callback.Call()
+	case <-batch.Full():
+		callback.Call()
+	case <-timer.C():
+		callback.Call()
+	}
+}
+
+// next returns the first boundary after now.
+func (a *AlignedWindowScheduler) next(now time.Time) time.Time {
+	shift := a.offset + a.jitter
+	return now.Add(-shift).Truncate(a.period).Add(a.period).Add(shift)
+}
+
+// alignedWindowSchedulerOption is a function that configures an AlignedWindowScheduler.
+type alignedWindowSchedulerOption func(*AlignedWindowScheduler)
+
+// WithAlignedWindowSchedulerJitter returns an option that shifts the boundaries of an AlignedWindowScheduler
+// by a random duration up to jitter, picked once, so replicas do not dispatch in lockstep.
+func WithAlignedWindowSchedulerJitter(jitter time.Duration) alignedWindowSchedulerOption {
+	return func(a *AlignedWindowScheduler) {
+		if jitter > 0 {
+			a.jitter = time.Duration(rand.Int63n(int64(jitter)))
+		}
+	}
+}
+
+// WithAlignedWindowSchedulerClock returns an option that sets the clock for an AlignedWindowScheduler.
+func WithAlignedWindowSchedulerClock(clock clock.Clock) alignedWindowSchedulerOption {
+	return func(a *AlignedWindowScheduler) {
+		a.clock = clock
+	}
+}
@@ -0,0 +1,121 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("AlignedWindowScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock    *clocktesting.FakeClock
+		mockBatch    *MockBatch
+		mockCallback *MockSchedulerCallback
+
+		dispatchTrigger chan struct{}
+		fullTrigger     chan struct{}
+		called          chan struct{}
+		returned        chan struct{}
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Date(2026, 1, 1, 12, 30, 15, int(300*time.Millisecond), time.UTC))
+
+		dispatchTrigger = make(chan struct{})
+		fullTrigger = make(chan struct{})
+		called = make(chan struct{})
+		returned = make(chan struct{})
+
+		mockBatch = NewMockBatch(ctrl)
+		mockBatch.EXPECT().Dispatch().Return(dispatchTrigger)
+		mockBatch.EXPECT().Full().Return(fullTrigger)
+
+		mockCallback = NewMockSchedulerCallback(ctrl)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+		Eventually(returned).Should(BeClosed())
+	})
+
+	schedule := func(scheduler Scheduler) {
+		go func() {
+			defer close(returned)
+			scheduler.Schedule(ctx, mockBatch, mockCallback)
+		}()
+	}
+
+	expectCall := func() {
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+	}
+
+	expectCallAfter := func(d time.Duration) {
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(d - time.Millisecond)
+		Consistently(called).ShouldNot(BeClosed())
+		fakeClock.Step(time.Millisecond)
+		Eventually(called).Should(BeClosed())
+	}
+
+	It("should call callback at next boundary", func() {
+		expectCall()
+		schedule(NewAlignedWindowScheduler(time.Second, 0, WithAlignedWindowSchedulerClock(fakeClock)))
+		expectCallAfter(700 * time.Millisecond)
+	})
+
+	It("should align boundaries to wall clock", func() {
+		expectCall()
+		schedule(NewAlignedWindowScheduler(time.Minute, 0, WithAlignedWindowSchedulerClock(fakeClock)))
+		expectCallAfter(44*time.Second + 700*time.Millisecond)
+	})
+
+	It("should shift boundaries by offset", func() {
+		expectCall()
+		schedule(NewAlignedWindowScheduler(time.Second, 200*time.Millisecond, WithAlignedWindowSchedulerClock(fakeClock)))
+		expectCallAfter(900 * time.Millisecond)
+	})
+
+	It("should shift boundaries by jitter", func() {
+		scheduler := NewAlignedWindowScheduler(time.Second, 0,
+			WithAlignedWindowSchedulerClock(fakeClock),
+			WithAlignedWindowSchedulerJitter(200*time.Millisecond),
+		).(*AlignedWindowScheduler)
+		Expect(scheduler.jitter).Should(BeNumerically(">=", 0))
+		Expect(scheduler.jitter).Should(BeNumerically("<", 200*time.Millisecond))
+
+		expectCall()
+		schedule(scheduler)
+		expectCallAfter(700*time.Millisecond + scheduler.jitter)
+	})
+
+	It("should call callback if dispatch triggered", func() {
+		expectCall()
+		schedule(NewAlignedWindowScheduler(time.Second, 0, WithAlignedWindowSchedulerClock(fakeClock)))
+		close(dispatchTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should call callback if full triggered", func() {
+		expectCall()
+		schedule(NewAlignedWindowScheduler(time.Second, 0, WithAlignedWindowSchedulerClock(fakeClock)))
+		close(fullTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+})
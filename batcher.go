@@ -1,7 +1,9 @@
 package batcher
 
 import (
+	"container/list"
 	"context"
+	"errors"
 	"sync"
 	"time"
 )
@@ -26,6 +28,17 @@ type Batch interface {
 	Full() <-chan struct{}
 	// Dispatch returns a channel that is closed when the batch is dispatched.
 	Dispatch() <-chan struct{}
+	// Appended returns a channel that is closed the next time a request is appended to the batch.
+	Appended() <-chan struct{}
+	// Len returns the number of requests appended to the batch.
+	Len() int
+	// Reached returns a channel that is closed once the batch holds at least n requests.
+	Reached(n int) <-chan struct{}
+	// Deadline returns the earliest deadline among the contexts of the requests, and false if none has one.
+	Deadline() (time.Time, bool)
+	// AfterReady arranges to call f in its own goroutine once the batch is full or flushed,
+	// and returns a function that stops the call, like context.AfterFunc.
+	AfterReady(f func()) (stop func() bool)
 }
 
 // Action is an interface for an action that can be performed on a batch of requests.
@@ -56,8 +69,9 @@ func New[REQ any, RES any](ctx context.Context, action Action[REQ, RES], options
 	b := &batcher[REQ, RES]{
 		ctx:     ctx,
 		closed:  make(chan bool),
-		batches: make(chan []*batch[REQ, RES], 1),
+		batches: make(chan *list.List, 1),
 		action:  action,
+		open:    map[string]*batch[REQ, RES]{},
 
 		batcherConfig: &batcherConfig{
 			scheduler:          NewTimeWindowScheduler(2 * time.Second),
@@ -66,7 +80,7 @@ func New[REQ any, RES any](ctx context.Context, action Action[REQ, RES], options
 		},
 	}
 
-	b.batches <- []*batch[REQ, RES]{}
+	b.batches <- list.New()
 
 	for _, option := range options {
 		option(b.batcherConfig)
@@ -76,6 +90,24 @@ func New[REQ any, RES any](ctx context.Context, action Action[REQ, RES], options
 		b.metrics = NewMetricSet("go", "batcher", nil)
 	}
 
+	b.observeConcurrencyLimit()
+	b.observeSchedulerWindow()
+
+	if b.circuitBreaker != nil {
+		metrics := b.metrics
+		warmUp, _ := b.concurrencyControl.(WarmUpConcurrencyControl)
+		b.removeCircuitListener = b.circuitBreaker.addListener(func(from, to CircuitState) {
+			metrics.CircuitBreakerStateGauge.Set(float64(to))
+			metrics.CircuitBreakerTransitionCounter.Inc()
+			// Ramp up again once the downstream has recovered, instead of hitting it at the full limit.
+			if warmUp != nil && from == CircuitHalfOpen && to == CircuitClosed {
+				warmUp.WarmUp()
+			}
+		})
+		// The circuit breaker may be shared and already open.
+		metrics.CircuitBreakerStateGauge.Set(float64(b.circuitBreaker.State()))
+	}
+
 	return b
 }
 
@@ -86,17 +118,47 @@ type batcher[REQ any, RES any] struct {
 	closed chan bool
 	wg     sync.WaitGroup
 
-	batches chan []*batch[REQ, RES]
+	// batches holds the pending batches in creation order, the channel guards it like a mutex.
+	batches chan *list.List
 	action  Action[REQ, RES]
+
+	// open holds the batch still accepting requests for each partition key, guarded by batches.
+	open map[string]*batch[REQ, RES]
+	// last is the done channel of the most recently created batch, guarded by batches.
+	last chan struct{}
+
+	// removeCircuitListener stops the batcher from following the circuit breaker, nil without one.
+	removeCircuitListener func()
 }
 
 // batch is a concrete implementation of the Batch interface.
 type batch[REQ any, RES any] struct {
-	full      chan struct{}
-	dispatch  chan struct{}
-	requests  []REQ
-	thunks    []Thunk[RES]
-	createdAt time.Time
+	key          string
+	full         chan struct{}
+	dispatch     chan struct{}
+	requests     []REQ
+	contexts     []context.Context
+	thunks       []Thunk[RES]
+	createdAt    time.Time
+	dispatchedAt time.Time
+
+	// prev is closed when the batch created before this one is done, done is closed when this batch is done.
+	prev <-chan struct{}
+	done chan struct{}
+
+	// element is the batch in the pending list, nil once dispatched, guarded by batches.
+	element *list.Element
+
+	// ready is cancelled once the batch is full, flushed or done.
+	ready     context.Context
+	markReady context.CancelFunc
+
+	// mu guards the size and the signals read by schedulers.
+	mu         sync.Mutex
+	size       int
+	deadline   time.Time
+	appended   signal
+	thresholds map[int]chan struct{}
 }
 
 // Full returns a channel that is closed when the batch is full.
@@ -109,6 +171,74 @@ func (b *batch[K, V]) Dispatch() <-chan struct{} {
 	return b.dispatch
 }
 
+// Appended returns a channel that is closed the next time a request is appended to the batch.
+func (b *batch[K, V]) Appended() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.appended.wait()
+}
+
+// AfterReady arranges to call f in its own goroutine once the batch is full or flushed,
+// and returns a function that stops the call.
+func (b *batch[K, V]) AfterReady(f func()) func() bool {
+	return context.AfterFunc(b.ready, f)
+}
+
+// Len returns the number of requests appended to the batch.
+func (b *batch[K, V]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.size
+}
+
+// Reached returns a channel that is closed once the batch holds at least n requests.
+func (b *batch[K, V]) Reached(n int) <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch, ok := b.thresholds[n]
+	if !ok {
+		ch = make(chan struct{})
+		if b.size >= n {
+			close(ch)
+			return ch
+		}
+		if b.thresholds == nil {
+			b.thresholds = map[int]chan struct{}{}
+		}
+		b.thresholds[n] = ch
+	}
+	return ch
+}
+
+// Deadline returns the earliest deadline among the contexts of the requests, and false if none has one.
+func (b *batch[K, V]) Deadline() (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.deadline, !b.deadline.IsZero()
+}
+
+// recordAppend records a request appended with ctx and fires the signals it crosses.
+func (b *batch[K, V]) recordAppend(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok && (b.deadline.IsZero() || deadline.Before(b.deadline)) {
+		b.deadline = deadline
+	}
+	b.size++
+	b.appended.fire()
+	for n, ch := range b.thresholds {
+		if b.size >= n {
+			close(ch)
+			delete(b.thresholds, n)
+		}
+	}
+}
+
 // Do adds a request to the batcher and returns a Thunk that will be filled with the result.
 func (b *batcher[REQ, RES]) Do(ctx context.Context, request REQ) Thunk[RES] {
 	b.metrics.DoActionCounter.Inc()
@@ -127,30 +257,52 @@ func (b *batcher[REQ, RES]) Do(ctx context.Context, request REQ) Thunk[RES] {
 	default:
 	}
 
-	if len(batches) == 0 || len(batches[len(batches)-1].requests) >= b.maxBatchSize {
+	key, _ := PartitionKeyFromContext(ctx)
+	bat, ok := b.open[key]
+	if !ok {
 		b.metrics.BatchCreatedCounter.Inc()
-		bat := &batch[REQ, RES]{
+		ready, markReady := context.WithCancel(context.Background())
+		bat = &batch[REQ, RES]{
+			key:       key,
 			full:      make(chan struct{}),
 			dispatch:  make(chan struct{}),
 			requests:  []REQ{},
+			contexts:  []context.Context{},
 			thunks:    []Thunk[RES]{},
 			createdAt: time.Now(),
+			prev:      b.last,
+			done:      make(chan struct{}),
+			ready:     ready,
+			markReady: markReady,
 		}
+		b.last = bat.done
+		b.open[key] = bat
 
-		batches = append(batches, bat)
+		bat.element = batches.PushBack(bat)
 		b.wg.Add(1)
 
 		b.metrics.SchedulerScheduleCounter.Inc()
-		go b.scheduler.Schedule(b.ctx, bat, NewSchedulerCallback(b.dispatch))
+		callback := NewSchedulerCallback(func() {
+			b.dispatch(bat)
+		})
+		if async, ok := b.scheduler.(AsyncScheduler); ok {
+			async.ScheduleAsync(b.ctx, bat, callback)
+		} else {
+			go b.scheduler.Schedule(b.ctx, bat, callback)
+		}
 	}
 
-	bat := batches[len(batches)-1]
 	bat.requests = append(bat.requests, request)
+	bat.contexts = append(bat.contexts, ctx)
 	bat.thunks = append(bat.thunks, thunk)
 
-	if len(batches) != 0 && len(batches[len(batches)-1].requests) >= b.maxBatchSize {
+	bat.recordAppend(ctx)
+
+	if len(bat.requests) >= b.maxBatchSize {
 		b.metrics.BatchFullCounter.Inc()
-		close(batches[len(batches)-1].full)
+		delete(b.open, key)
+		close(bat.full)
+		bat.markReady()
 	}
 
 	b.batches <- batches
@@ -163,56 +315,118 @@ func (b *batcher[REQ, RES]) Shutdown() error {
 	close(b.closed)
 	b.flushall()
 	b.wg.Wait()
+	if b.removeCircuitListener != nil {
+		b.removeCircuitListener()
+	}
 	return nil
 }
 
 // flushall flushes all batches in the batcher.
 func (b *batcher[REQ, RES]) flushall() {
 	batches := <-b.batches
-	for _, batch := range batches {
+	for element := batches.Front(); element != nil; element = element.Next() {
+		batch := element.Value.(*batch[REQ, RES])
 		close(batch.dispatch)
+		batch.markReady()
 	}
 	b.batches <- batches
 }
 
-// dispatch dispatches the first batch in the batcher.
-func (b *batcher[REQ, RES]) dispatch() {
+// dispatch removes the batch from the batcher and performs it.
+// It does nothing if the batch was already dispatched.
+func (b *batcher[REQ, RES]) dispatch(batch *batch[REQ, RES]) {
 	b.metrics.SchedulerCallbackCounter.Inc()
-	ctx := b.ctx
 	batches := <-b.batches
 
-	if len(batches) == 0 {
+	if batch.element == nil {
 		b.batches <- batches
 		return
 	}
-	batch := batches[0]
+
+	if b.open[batch.key] == batch {
+		delete(b.open, batch.key)
+	}
 
 	b.metrics.BatchStartedCounter.Inc()
-	b.batches <- batches[1:]
+	batch.dispatchedAt = time.Now()
+	batches.Remove(batch.element)
+	batch.element = nil
+	b.batches <- batches
+
+	defer b.done(batch)
+
+	if b.orderedDispatch && batch.prev != nil {
+		select {
+		case <-b.ctx.Done():
+			b.reject(b.ctx, batch, b.ctx.Err())
+			return
+		case <-batch.prev:
+		}
+	}
+
+	probe := false
+	if b.circuitBreaker != nil {
+		allowed, isProbe, err := b.circuitBreaker.allow(len(batch.requests))
+		if err != nil {
+			b.metrics.CircuitBreakerRejectCounter.Inc()
+			b.reject(b.ctx, batch, err)
+			return
+		}
+
+		if allowed < len(batch.requests) {
+			for _, thunk := range batch.thunks[allowed:] {
+				b.metrics.ThunkErrorCounter.Inc()
+				thunk.Error(b.ctx, ErrCircuitOpen)
+			}
+			batch.requests = batch.requests[:allowed]
+			batch.contexts = batch.contexts[:allowed]
+			batch.thunks = batch.thunks[:allowed]
+		}
+		probe = isProbe
+	}
+
+	ctx := ContextWithBatchMetadata(b.ctx, newBatchMetadata(batch.contexts))
+	if batch.key != "" {
+		ctx = ContextWithPartitionKey(ctx, batch.key)
+	}
+	if priority, ok := batchPriority(batch.contexts); ok {
+		ctx = ContextWithPriority(ctx, priority)
+	}
 
 	b.metrics.BatchSizeHistogram.Observe(float64(len(batch.requests)))
 	b.metrics.CouncurrencyControlAcquireCounter.Inc()
-	token, err := b.concurrencyControl.Acquire(ctx)
+	token, err := acquireN(ctx, b.concurrencyControl, len(batch.requests))
 
 	if err != nil {
 		b.metrics.ConcurrencyControlErrorCounter.Inc()
-		for _, thunk := range batch.thunks {
-			b.metrics.ThunkErrorCounter.Inc()
-			thunk.Error(ctx, err)
+		if b.circuitBreaker != nil {
+			b.circuitBreaker.abort(probe)
 		}
-
-		b.metrics.BatchDoneCounter.Inc()
-		b.metrics.BatchLifetimeHistogram.Observe(time.Since(batch.createdAt).Seconds())
-		b.wg.Done()
+		b.reject(ctx, batch, err)
 		return
 	}
 
 	b.metrics.ConcurrencyControlTokenCounter.Inc()
 	b.metrics.BatchActionPerformCounter.Inc()
-	results := b.action.Perform(ctx, batch.requests)
+	performedAt := time.Now()
+	results, err := b.perform(ctx, batch.requests, token)
+	b.observeBatch(batch, performedAt)
+
+	failure := batchError(results, err)
+
+	b.observeConcurrencyLimit()
 
-	b.metrics.ConcurrencyControlReleaseCounter.Inc()
-	token.Release()
+	if b.circuitBreaker != nil {
+		b.circuitBreaker.record(probe, failure != nil)
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrActionTimeout) {
+			b.metrics.BatchActionTimeoutCounter.Inc()
+		}
+		b.reject(ctx, batch, err)
+		return
+	}
 
 	for index, res := range results {
 		if res.Error != nil {
@@ -223,8 +437,205 @@ func (b *batcher[REQ, RES]) dispatch() {
 			batch.thunks[index].Set(ctx, res.Response)
 		}
 	}
+}
+
+// perform performs the action on the requests and releases the concurrency token once Perform returns.
+// With an action timeout it runs Perform under a deadline and stops waiting once the deadline passes,
+// releasing the tokens so a hung Perform can not hold them.
+// With hedging it starts another Perform every hedge delay until one of them returns, the first result wins.
+// Every attempt holds its own token until its Perform returns, so the losers still count against the control.
+func (b *batcher[REQ, RES]) perform(ctx context.Context, requests []REQ, token ConcurrencyToken) ([]Response[RES], error) {
+	if b.actionTimeout <= 0 && (b.hedgeDelay <= 0 || b.maxHedges <= 0) {
+		results := b.action.Perform(ctx, requests)
+		b.metrics.ConcurrencyControlReleaseCounter.Inc()
+		releaseToken(token, batchError(results, nil))
+		return results, nil
+	}
+
+	if b.actionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, b.actionTimeout, ErrActionTimeout)
+		defer cancel()
+	}
+
+	attemptCtx, cancelAttempts := context.WithCancel(ctx)
+	defer cancelAttempts()
+
+	tokens := &attemptTokens{}
+	defer tokens.close()
+
+	results := make(chan hedgeResult[RES], b.maxHedges+1)
+	release, _ := tokens.add(token, b.metrics)
+	go func() {
+		attempt := b.action.Perform(attemptCtx, requests)
+		tokens.finish(release, batchError(attempt, nil))
+		results <- hedgeResult[RES]{results: attempt}
+	}()
+
+	var timer *time.Timer
+	var hedgeTimer <-chan time.Time
+	if b.hedgeDelay > 0 && b.maxHedges > 0 {
+		timer = time.NewTimer(b.hedgeDelay)
+		defer timer.Stop()
+		hedgeTimer = timer.C
+	}
+
+	for started := 0; ; {
+		select {
+		case result := <-results:
+			if result.hedged {
+				b.metrics.BatchHedgeWinCounter.Inc()
+			}
+			return result.results, nil
+		case <-hedgeTimer:
+			started++
+			b.metrics.BatchHedgeCounter.Inc()
+			go b.hedge(attemptCtx, requests, tokens, results)
+
+			hedgeTimer = nil
+			if started < b.maxHedges {
+				timer.Reset(b.hedgeDelay)
+				hedgeTimer = timer.C
+			}
+		case <-ctx.Done():
+			tokens.releaseAll(context.Cause(ctx))
+			return nil, context.Cause(ctx)
+		}
+	}
+}
+
+// hedge acquires its own concurrency token and performs a duplicate of the action,
+// releasing the token once its Perform returns.
+func (b *batcher[REQ, RES]) hedge(ctx context.Context, requests []REQ, tokens *attemptTokens, results chan<- hedgeResult[RES]) {
+	b.metrics.CouncurrencyControlAcquireCounter.Inc()
+	token, err := acquireN(ctx, b.concurrencyControl, len(requests))
+	if err != nil {
+		// Hedges still waiting when the winner returns are cancelled, that is not an error of the control.
+		if ctx.Err() == nil {
+			b.metrics.ConcurrencyControlErrorCounter.Inc()
+		}
+		return
+	}
+	b.metrics.ConcurrencyControlTokenCounter.Inc()
+
+	release, ok := tokens.add(token, b.metrics)
+	if !ok {
+		return
+	}
+
+	b.metrics.BatchActionPerformCounter.Inc()
+	attempt := b.action.Perform(ctx, requests)
+	tokens.finish(release, batchError(attempt, nil))
+	results <- hedgeResult[RES]{results: attempt, hedged: true}
+}
+
+// hedgeResult is the result of one Perform attempt.
+type hedgeResult[RES any] struct {
+	results []Response[RES]
+	hedged  bool
+}
+
+// attemptTokens holds the concurrency tokens of the Perform attempts of a batch.
+type attemptTokens struct {
+	mu       sync.Mutex
+	releases []func(error)
+	closed   bool
+}
+
+// add keeps the token of an attempt about to perform and returns a function that releases it once.
+// If no more attempts may start, it releases the token right away and returns false.
+func (a *attemptTokens) add(token ConcurrencyToken, metrics *MetricSet) (func(error), bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		metrics.ConcurrencyControlReleaseCounter.Inc()
+		token.Release()
+		return nil, false
+	}
+
+	once := &sync.Once{}
+	release := func(err error) {
+		once.Do(func() {
+			metrics.ConcurrencyControlReleaseCounter.Inc()
+			releaseToken(token, err)
+		})
+	}
+	a.releases = append(a.releases, release)
+	return release, true
+}
+
+// close stops more attempts from starting, the running ones keep their tokens until their Perform returns.
+func (a *attemptTokens) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.closed = true
+}
+
+// finish releases the token of an attempt whose Perform returned. It stops more attempts from starting first,
+// so a hedge waiting for the token does not perform once there is already a result.
+func (a *attemptTokens) finish(release func(error), err error) {
+	a.close()
+	release(err)
+}
+
+// releaseAll stops more attempts from starting and releases the tokens still held,
+// for attempts that outlive the action timeout.
+func (a *attemptTokens) releaseAll(err error) {
+	a.mu.Lock()
+	a.closed = true
+	releases := a.releases
+	a.releases = nil
+	a.mu.Unlock()
+
+	for _, release := range releases {
+		release(err)
+	}
+}
+
+// observeConcurrencyLimit records the current limit of the concurrency control, if it reports one.
+func (b *batcher[REQ, RES]) observeConcurrencyLimit() {
+	if limiter, ok := b.concurrencyControl.(concurrencyLimiter); ok {
+		b.metrics.ConcurrencyControlLimitGauge.Set(float64(limiter.Limit()))
+	}
+}
+
+// observeSchedulerWindow sets the scheduler window gauge if the scheduler, or one it is composed of, reports its window.
+func (b *batcher[REQ, RES]) observeSchedulerWindow() {
+	if scheduler, ok := findWindowScheduler(b.scheduler); ok {
+		b.metrics.SchedulerWindowGauge.Set(scheduler.Window().Seconds())
+	}
+}
+
+// observeBatch tells the scheduler how the batch went if it observes batches.
+func (b *batcher[REQ, RES]) observeBatch(batch *batch[REQ, RES], performedAt time.Time) {
+	scheduler, ok := b.scheduler.(ObservingScheduler)
+	if !ok {
+		return
+	}
+
+	scheduler.Observe(BatchObservation{
+		Size:    len(batch.requests),
+		Wait:    batch.dispatchedAt.Sub(batch.createdAt),
+		Perform: time.Since(performedAt),
+	})
+	b.observeSchedulerWindow()
+}
+
+// reject fills every thunk of the batch with the provided error.
+func (b *batcher[REQ, RES]) reject(ctx context.Context, batch *batch[REQ, RES], err error) {
+	for _, thunk := range batch.thunks {
+		b.metrics.ThunkErrorCounter.Inc()
+		thunk.Error(ctx, err)
+	}
+}
 
+// done marks the batch as done and lets the next batch run when dispatch is ordered.
+func (b *batcher[REQ, RES]) done(batch *batch[REQ, RES]) {
 	b.metrics.BatchDoneCounter.Inc()
 	b.metrics.BatchLifetimeHistogram.Observe(time.Since(batch.createdAt).Seconds())
+	close(batch.done)
+	batch.markReady()
 	b.wg.Done()
 }
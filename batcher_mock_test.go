@@ -11,6 +11,7 @@ package batcher
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -89,6 +90,49 @@ func (m *MockBatch) EXPECT() *MockBatchMockRecorder {
 	return m.recorder
 }
 
+// AfterReady mocks base method.
+func (m *MockBatch) AfterReady(f func()) func() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AfterReady", f)
+	ret0, _ := ret[0].(func() bool)
+	return ret0
+}
+
+// AfterReady indicates an expected call of AfterReady.
+func (mr *MockBatchMockRecorder) AfterReady(f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterReady", reflect.TypeOf((*MockBatch)(nil).AfterReady), f)
+}
+
+// Appended mocks base method.
+func (m *MockBatch) Appended() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Appended")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Appended indicates an expected call of Appended.
+func (mr *MockBatchMockRecorder) Appended() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Appended", reflect.TypeOf((*MockBatch)(nil).Appended))
+}
+
+// Deadline mocks base method.
+func (m *MockBatch) Deadline() (time.Time, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deadline")
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Deadline indicates an expected call of Deadline.
+func (mr *MockBatchMockRecorder) Deadline() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deadline", reflect.TypeOf((*MockBatch)(nil).Deadline))
+}
+
 // Dispatch mocks base method.
 func (m *MockBatch) Dispatch() <-chan struct{} {
 	m.ctrl.T.Helper()
@@ -117,6 +161,34 @@ func (mr *MockBatchMockRecorder) Full() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Full", reflect.TypeOf((*MockBatch)(nil).Full))
 }
 
+// Len mocks base method.
+func (m *MockBatch) Len() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Len")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Len indicates an expected call of Len.
+func (mr *MockBatchMockRecorder) Len() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockBatch)(nil).Len))
+}
+
+// Reached mocks base method.
+func (m *MockBatch) Reached(n int) <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reached", n)
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Reached indicates an expected call of Reached.
+func (mr *MockBatchMockRecorder) Reached(n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reached", reflect.TypeOf((*MockBatch)(nil).Reached), n)
+}
+
 // MockAction is a mock of Action interface.
 type MockAction[REQ any, RES any] struct {
 	ctrl     *gomock.Controller
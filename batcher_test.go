@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gleak"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -69,6 +70,7 @@ var _ = Describe("Batcher", func() {
 		BeforeEach(func() {
 			wg = &sync.WaitGroup{}
 			action = NewMockAction[string, string](ctrl)
+			options = nil
 		})
 
 		JustBeforeEach(func() {
@@ -98,7 +100,7 @@ var _ = Describe("Batcher", func() {
 					}
 				}
 
-				action.EXPECT().Perform(ctx, requests).Times(1).Return(responses)
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).Return(responses)
 			})
 
 			It("should batch do request", func() {
@@ -144,7 +146,7 @@ var _ = Describe("Batcher", func() {
 					}
 				}
 
-				action.EXPECT().Perform(ctx, requests).Times(1).Return(responses)
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).Return(responses)
 			})
 
 			It("should have corrsponding value and error", func() {
@@ -191,7 +193,7 @@ var _ = Describe("Batcher", func() {
 				}
 
 				for i := 0; i < actionCount; i += batchSize {
-					action.EXPECT().Perform(ctx, requests[i:i+batchSize]).Times(1).Return(responses[i : i+batchSize])
+					action.EXPECT().Perform(gomock.Any(), requests[i:i+batchSize]).Times(1).Return(responses[i : i+batchSize])
 				}
 			})
 
@@ -238,7 +240,7 @@ var _ = Describe("Batcher", func() {
 				}
 
 				for i := 0; i < actionCount; i += batchSize {
-					action.EXPECT().Perform(ctx, requests[i:i+batchSize]).Times(1).Return(responses[i : i+batchSize])
+					action.EXPECT().Perform(gomock.Any(), requests[i:i+batchSize]).Times(1).Return(responses[i : i+batchSize])
 				}
 			})
 
@@ -286,7 +288,7 @@ var _ = Describe("Batcher", func() {
 				}
 
 				for i := 0; i < actionCount; i += batchSize {
-					action.EXPECT().Perform(ctx, requests[i:i+batchSize]).Times(1).Return(responses[i : i+batchSize])
+					action.EXPECT().Perform(gomock.Any(), requests[i:i+batchSize]).Times(1).Return(responses[i : i+batchSize])
 				}
 			})
 
@@ -339,7 +341,7 @@ var _ = Describe("Batcher", func() {
 				expectedErr = fmt.Errorf("error")
 
 				cc.EXPECT().Acquire(gomock.Any()).MinTimes(1).Return(nil, expectedErr)
-				action.EXPECT().Perform(ctx, gomock.Any()).Times(0)
+				action.EXPECT().Perform(gomock.Any(), gomock.Any()).Times(0)
 			})
 
 			It("should return error", func() {
@@ -361,6 +363,773 @@ var _ = Describe("Batcher", func() {
 			})
 		})
 
+		Describe("can dispatch in order", func() {
+			var (
+				actionCount int
+				requests    []string
+				performed   []string
+				mu          sync.Mutex
+			)
+
+			BeforeEach(func() {
+				actionCount = gofakeit.Number(3, 5)
+				options = append(options,
+					WithMaxBatchSize(1),
+					WithScheduler(NewTestGracefulScheduler()),
+					WithOrderedDispatch(),
+				)
+				requests = make([]string, actionCount)
+				performed = []string{}
+
+				for i := 0; i < actionCount; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+				}
+
+				action.EXPECT().Perform(gomock.Any(), gomock.Any()).Times(actionCount).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+					var index int
+					fmt.Sscanf(reqs[0], "req: #%d", &index)
+					<-time.After(time.Duration(actionCount-index) * 10 * time.Millisecond)
+
+					mu.Lock()
+					performed = append(performed, reqs[0])
+					mu.Unlock()
+					return []Response[string]{{Response: reqs[0]}}
+				})
+			})
+
+			It("should perform and resolve batches in creation order", func() {
+				thunks := make([]Thunk[string], actionCount)
+				for i := 0; i < actionCount; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+
+				b.Shutdown()
+
+				for i := 0; i < actionCount; i++ {
+					val, err := thunks[i].Await(ctx)
+					Expect(err).To(BeNil())
+					Expect(val).To(Equal(requests[i]))
+				}
+				Expect(performed).To(Equal(requests))
+			})
+		})
+
+		Describe("can timeout action", func() {
+			var (
+				batchSize int
+				requests  []string
+				release   chan struct{}
+
+				cc *limitedConcurrencyControl
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				release = make(chan struct{})
+				cc = NewLimitedConcurrencyControl(1).(*limitedConcurrencyControl)
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithConcurrencyControl(cc),
+					WithActionTimeout(20*time.Millisecond),
+				)
+				requests = make([]string, batchSize)
+
+				for i := 0; i < batchSize; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+				}
+
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+					_, ok := ctx.Deadline()
+					Expect(ok).To(BeTrue())
+					<-release
+					return nil
+				})
+			})
+
+			AfterEach(func() {
+				close(release)
+			})
+
+			It("should reject thunks with action timeout and release token", func() {
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+
+				for i := 0; i < batchSize; i++ {
+					val, err := thunks[i].Await(ctx)
+					Expect(err).To(MatchError(ErrActionTimeout))
+					Expect(err).To(MatchError(context.DeadlineExceeded))
+					Expect(val).To(Equal(""))
+				}
+
+				Eventually(func() int { return cc.sem.used }).Should(Equal(0))
+			})
+		})
+
+		Describe("can expose request contexts to action", func() {
+			var (
+				actionCount int
+				requests    []string
+			)
+
+			BeforeEach(func() {
+				actionCount = gofakeit.Number(3, 5)
+				options = append(options, WithMaxBatchSize(actionCount))
+				requests = make([]string, actionCount)
+
+				for i := 0; i < actionCount; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+				}
+
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+					items := BatchFromContext(ctx).Items()
+					responses := make([]Response[string], len(reqs))
+					for i, item := range items {
+						responses[i] = Response[string]{Response: item.Value(metadataTestKey{}).(string)}
+					}
+					return responses
+				})
+			})
+
+			It("should pass item metadata to action", func() {
+				thunks := make([]Thunk[string], actionCount)
+				for i := 0; i < actionCount; i++ {
+					thunks[i] = b.Do(context.WithValue(ctx, metadataTestKey{}, requests[i]), requests[i])
+				}
+
+				for i := 0; i < actionCount; i++ {
+					val, err := thunks[i].Await(ctx)
+					Expect(err).To(BeNil())
+					Expect(val).To(Equal(requests[i]))
+				}
+			})
+		})
+
+		Describe("can hedge slow action", func() {
+			var (
+				batchSize int
+				requests  []string
+				responses []Response[string]
+				metrics   *MetricSet
+				cancelled chan struct{}
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				metrics = NewMetricSet("go", "batcher", nil)
+				cancelled = make(chan struct{})
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithMetricSet(metrics),
+					WithHedging(10*time.Millisecond, 1),
+				)
+				requests = make([]string, batchSize)
+				responses = make([]Response[string], batchSize)
+
+				for i := 0; i < batchSize; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+					responses[i] = Response[string]{
+						Response: fmt.Sprintf("res: #%d", i),
+					}
+				}
+
+				gomock.InOrder(
+					action.EXPECT().Perform(gomock.Any(), requests).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+						<-ctx.Done()
+						close(cancelled)
+						return nil
+					}),
+					action.EXPECT().Perform(gomock.Any(), requests).Times(1).Return(responses),
+				)
+			})
+
+			It("should use the result of the hedged action and cancel the slow one", func() {
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+
+				for i := 0; i < batchSize; i++ {
+					val, err := thunks[i].Await(ctx)
+					Expect(err).To(BeNil())
+					Expect(val).To(Equal(responses[i].Response))
+				}
+
+				Eventually(cancelled).Should(BeClosed())
+				Expect(testutil.ToFloat64(metrics.BatchHedgeCounter)).To(Equal(1.0))
+				Expect(testutil.ToFloat64(metrics.BatchHedgeWinCounter)).To(Equal(1.0))
+			})
+		})
+
+		Describe("can hold token of losing attempt until it returns", func() {
+			var (
+				batchSize int
+				requests  []string
+				responses []Response[string]
+				release   chan struct{}
+
+				cc *limitedConcurrencyControl
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				release = make(chan struct{})
+				cc = NewLimitedConcurrencyControl(2).(*limitedConcurrencyControl)
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithConcurrencyControl(cc),
+					WithHedging(10*time.Millisecond, 1),
+				)
+				requests = make([]string, batchSize)
+				responses = make([]Response[string], batchSize)
+
+				for i := 0; i < batchSize; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+					responses[i] = Response[string]{
+						Response: fmt.Sprintf("res: #%d", i),
+					}
+				}
+
+				gomock.InOrder(
+					action.EXPECT().Perform(gomock.Any(), requests).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+						<-release
+						return nil
+					}),
+					action.EXPECT().Perform(gomock.Any(), requests).Times(1).Return(responses),
+				)
+			})
+
+			It("should release the token of the slow attempt once its Perform returns", func() {
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+
+				for i := 0; i < batchSize; i++ {
+					val, err := thunks[i].Await(ctx)
+					Expect(err).To(BeNil())
+					Expect(val).To(Equal(responses[i].Response))
+				}
+
+				used := func() int {
+					cc.sem.mu.Lock()
+					defer cc.sem.mu.Unlock()
+					return cc.sem.used
+				}
+				Eventually(used).Should(Equal(1))
+				Consistently(used).Should(Equal(1))
+
+				close(release)
+				Eventually(used).Should(Equal(0))
+			})
+		})
+
+		Describe("can cancel hedge waiting for concurrency", func() {
+			var (
+				batchSize int
+				requests  []string
+				responses []Response[string]
+				metrics   *MetricSet
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				metrics = NewMetricSet("go", "batcher", nil)
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithMetricSet(metrics),
+					WithConcurrencyControl(NewLimitedConcurrencyControl(1)),
+					WithHedging(10*time.Millisecond, 1),
+				)
+				requests = make([]string, batchSize)
+				responses = make([]Response[string], batchSize)
+
+				for i := 0; i < batchSize; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+					responses[i] = Response[string]{
+						Response: fmt.Sprintf("res: #%d", i),
+					}
+				}
+
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+					Eventually(func() float64 {
+						return testutil.ToFloat64(metrics.BatchHedgeCounter)
+					}).Should(Equal(1.0))
+					return responses
+				})
+			})
+
+			It("should not count cancelled hedge as concurrency control error", func() {
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+
+				for i := 0; i < batchSize; i++ {
+					val, err := thunks[i].Await(ctx)
+					Expect(err).To(BeNil())
+					Expect(val).To(Equal(responses[i].Response))
+				}
+
+				Consistently(func() float64 {
+					return testutil.ToFloat64(metrics.ConcurrencyControlErrorCounter)
+				}).Should(Equal(0.0))
+				Expect(testutil.ToFloat64(metrics.BatchHedgeWinCounter)).To(Equal(0.0))
+			})
+		})
+
+		Describe("can reject batches when circuit is open", func() {
+			var (
+				batchSize int
+				requests  []string
+				responses []Response[string]
+				metrics   *MetricSet
+				cb        *CircuitBreaker
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				metrics = NewMetricSet("go", "batcher", nil)
+				cb = NewCircuitBreaker(
+					WithCircuitBreakerWindow(1, 1),
+					WithCircuitBreakerOpenTimeout(time.Hour),
+				)
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithMetricSet(metrics),
+					WithCircuitBreaker(cb),
+				)
+				requests = make([]string, batchSize)
+				responses = make([]Response[string], batchSize)
+
+				for i := 0; i < batchSize; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+					responses[i] = Response[string]{
+						Error: fmt.Errorf("err: #%d", i),
+					}
+				}
+
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).Return(responses)
+			})
+
+			It("should open after failed batch", func() {
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+				for i := 0; i < batchSize; i++ {
+					_, err := thunks[i].Await(ctx)
+					Expect(err).To(Equal(responses[i].Error))
+				}
+
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+				for i := 0; i < batchSize; i++ {
+					_, err := thunks[i].Await(ctx)
+					Expect(err).To(MatchError(ErrCircuitOpen))
+				}
+
+				Expect(testutil.ToFloat64(metrics.CircuitBreakerStateGauge)).To(Equal(float64(CircuitOpen)))
+				Expect(testutil.ToFloat64(metrics.CircuitBreakerRejectCounter)).To(Equal(1.0))
+			})
+
+			It("should report state of shared circuit and stop following it after shutdown", func() {
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+				for i := 0; i < batchSize; i++ {
+					_, err := thunks[i].Await(ctx)
+					Expect(err).To(Equal(responses[i].Error))
+				}
+
+				listeners := func() int {
+					cb.mu.Lock()
+					defer cb.mu.Unlock()
+					return len(cb.listeners)
+				}
+
+				joinedMetrics := NewMetricSet("go", "batcher", nil)
+				joined := New[string, string](ctx, action, WithCircuitBreaker(cb), WithMetricSet(joinedMetrics))
+				Expect(testutil.ToFloat64(joinedMetrics.CircuitBreakerStateGauge)).To(Equal(float64(CircuitOpen)))
+				Expect(listeners()).To(Equal(2))
+
+				Expect(joined.Shutdown()).To(Succeed())
+				Expect(listeners()).To(Equal(1))
+				Expect(b.Shutdown()).To(Succeed())
+				Expect(listeners()).To(Equal(0))
+			})
+		})
+
+		Describe("can acquire weighted token by batch size", func() {
+			var (
+				batchSize int
+				requests  []string
+				responses []Response[string]
+
+				cc *weightedConcurrencyControl
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				cc = NewWeightedConcurrencyControl(batchSize * 2).(*weightedConcurrencyControl)
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithConcurrencyControl(cc),
+				)
+				requests = make([]string, batchSize)
+				responses = make([]Response[string], batchSize)
+
+				for i := 0; i < batchSize; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+					responses[i] = Response[string]{
+						Response: fmt.Sprintf("res: #%d", i),
+					}
+				}
+
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+					cc.sem.mu.Lock()
+					defer cc.sem.mu.Unlock()
+					Expect(cc.sem.used).To(Equal(batchSize))
+					return responses
+				})
+			})
+
+			It("should acquire token weighing the batch size", func() {
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+
+				for i := 0; i < batchSize; i++ {
+					val, err := thunks[i].Await(ctx)
+					Expect(err).To(BeNil())
+					Expect(val).To(Equal(responses[i].Response))
+				}
+				Expect(cc.sem.used).To(Equal(0))
+			})
+		})
+
+		Describe("can report adaptive concurrency limit", func() {
+			var (
+				batchSize int
+				requests  []string
+				responses []Response[string]
+				metrics   *MetricSet
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				metrics = NewMetricSet("go", "batcher", nil)
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithMetricSet(metrics),
+					WithConcurrencyControl(NewAdaptiveConcurrencyControl(8, 1, 8)),
+				)
+				requests = make([]string, batchSize)
+				responses = make([]Response[string], batchSize)
+
+				for i := 0; i < batchSize; i++ {
+					requests[i] = fmt.Sprintf("req: #%d", i)
+					responses[i] = Response[string]{
+						Error: fmt.Errorf("err: #%d", i),
+					}
+				}
+
+				action.EXPECT().Perform(gomock.Any(), requests).Times(1).Return(responses)
+			})
+
+			It("should lower the limit gauge after failed batch", func() {
+				Expect(testutil.ToFloat64(metrics.ConcurrencyControlLimitGauge)).To(Equal(8.0))
+
+				thunks := make([]Thunk[string], batchSize)
+				for i := 0; i < batchSize; i++ {
+					thunks[i] = b.Do(ctx, requests[i])
+				}
+				for i := 0; i < batchSize; i++ {
+					_, err := thunks[i].Await(ctx)
+					Expect(err).To(Equal(responses[i].Error))
+				}
+
+				Expect(testutil.ToFloat64(metrics.ConcurrencyControlLimitGauge)).To(Equal(7.0))
+			})
+		})
+
+		Describe("can batch by partition key", func() {
+			var (
+				batchSize int
+				keys      []string
+				requests  map[string][]string
+			)
+
+			BeforeEach(func() {
+				batchSize = gofakeit.Number(3, 5)
+				keys = []string{"foo", "bar"}
+				options = append(options,
+					WithMaxBatchSize(batchSize),
+					WithConcurrencyControl(NewKeyedConcurrencyControl(1)),
+				)
+				requests = map[string][]string{}
+
+				for _, key := range keys {
+					for i := 0; i < batchSize; i++ {
+						requests[key] = append(requests[key], fmt.Sprintf("%s: #%d", key, i))
+					}
+
+					key := key
+					action.EXPECT().Perform(gomock.Any(), requests[key]).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+						partition, ok := PartitionKeyFromContext(ctx)
+						Expect(ok).To(BeTrue())
+						Expect(partition).To(Equal(key))
+
+						responses := make([]Response[string], len(reqs))
+						for i, req := range reqs {
+							responses[i] = Response[string]{Response: req}
+						}
+						return responses
+					})
+				}
+			})
+
+			It("should not batch requests of different partition together", func() {
+				thunks := map[string][]Thunk[string]{}
+				for i := 0; i < batchSize; i++ {
+					for _, key := range keys {
+						thunks[key] = append(thunks[key], b.Do(ContextWithPartitionKey(ctx, key), requests[key][i]))
+					}
+				}
+
+				for _, key := range keys {
+					for i := 0; i < batchSize; i++ {
+						val, err := thunks[key][i].Await(ctx)
+						Expect(err).To(BeNil())
+						Expect(val).To(Equal(requests[key][i]))
+					}
+				}
+			})
+		})
+
+		Describe("can signal appends to scheduler", func() {
+			var (
+				scheduled chan Batch
+				callbacks chan SchedulerCallback
+			)
+
+			BeforeEach(func() {
+				scheduled = make(chan Batch, 1)
+				callbacks = make(chan SchedulerCallback, 1)
+				scheduler := NewMockScheduler(ctrl)
+				scheduler.EXPECT().Schedule(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, batch Batch, callback SchedulerCallback) {
+					scheduled <- batch
+					callbacks <- callback
+				})
+				options = append(options, WithMaxBatchSize(3), WithScheduler(scheduler))
+
+				action.EXPECT().Perform(gomock.Any(), []string{"foo", "bar"}).Times(1).Return([]Response[string]{
+					{Response: "foo"},
+					{Response: "bar"},
+				})
+			})
+
+			It("should close appended on every request", func() {
+				first := b.Do(ctx, "foo")
+				var bat Batch
+				Eventually(scheduled).Should(Receive(&bat))
+
+				appended := bat.Appended()
+				Consistently(appended).ShouldNot(BeClosed())
+				second := b.Do(ctx, "bar")
+				Eventually(appended).Should(BeClosed())
+				Expect(bat.Appended()).ShouldNot(BeClosed())
+				Expect(bat.Len()).To(Equal(2))
+
+				var callback SchedulerCallback
+				Eventually(callbacks).Should(Receive(&callback))
+				callback.Call()
+
+				Expect(first.Await(ctx)).To(Equal("foo"))
+				Expect(second.Await(ctx)).To(Equal("bar"))
+			})
+		})
+
+		Describe("can signal size to scheduler", func() {
+			BeforeEach(func() {
+				options = append(options, WithMaxBatchSize(3), WithScheduler(NewMinSizeScheduler(2, 0)))
+
+				action.EXPECT().Perform(gomock.Any(), []string{"foo", "bar"}).Times(1).Return([]Response[string]{
+					{Response: "foo"},
+					{Response: "bar"},
+				})
+			})
+
+			It("should close reached once batch holds enough requests", func() {
+				first := b.Do(ctx, "foo")
+				batches := <-b.batches
+				bat := b.open[""]
+				b.batches <- batches
+				Expect(bat.Len()).To(Equal(1))
+				Expect(bat.Reached(1)).To(BeClosed())
+
+				_, ok := bat.Deadline()
+				Expect(ok).To(BeFalse())
+
+				reached := bat.Reached(2)
+				Consistently(reached).ShouldNot(BeClosed())
+				deadline := time.Now().Add(time.Hour)
+				deadlineCtx, cancel := context.WithDeadline(ctx, deadline)
+				defer cancel()
+				second := b.Do(deadlineCtx, "bar")
+				Eventually(reached).Should(BeClosed())
+				earliest, ok := bat.Deadline()
+				Expect(ok).To(BeTrue())
+				Expect(earliest).To(Equal(deadline))
+
+				Expect(first.Await(ctx)).To(Equal("foo"))
+				Expect(second.Await(ctx)).To(Equal("bar"))
+			})
+		})
+
+		Describe("can report scheduler window", func() {
+			var (
+				metrics   *MetricSet
+				scheduler *LatencySLOScheduler
+			)
+
+			BeforeEach(func() {
+				metrics = NewMetricSet("go", "batcher", nil)
+				scheduler = NewLatencySLOScheduler(time.Second).(*LatencySLOScheduler)
+				options = append(options, WithMaxBatchSize(2), WithScheduler(scheduler), WithMetricSet(metrics))
+
+				action.EXPECT().Perform(gomock.Any(), []string{"foo", "bar"}).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+					time.Sleep(50 * time.Millisecond)
+					return []Response[string]{{Response: "foo"}, {Response: "bar"}}
+				})
+			})
+
+			It("should observe batches and export window", func() {
+				Expect(testutil.ToFloat64(metrics.SchedulerWindowGauge)).To(Equal(0.5))
+
+				first := b.Do(ctx, "foo")
+				second := b.Do(ctx, "bar")
+				Expect(first.Await(ctx)).To(Equal("foo"))
+				Expect(second.Await(ctx)).To(Equal("bar"))
+
+				Eventually(func() float64 {
+					return testutil.ToFloat64(metrics.SchedulerWindowGauge)
+				}).Should(BeNumerically("<", 0.95))
+				Expect(testutil.ToFloat64(metrics.SchedulerWindowGauge)).To(Equal(scheduler.Window().Seconds()))
+			})
+		})
+
+		Describe("can observe schedulers inside composite", func() {
+			var (
+				metrics   *MetricSet
+				scheduler *LatencySLOScheduler
+			)
+
+			BeforeEach(func() {
+				metrics = NewMetricSet("go", "batcher", nil)
+				scheduler = NewLatencySLOScheduler(time.Second).(*LatencySLOScheduler)
+				options = append(options,
+					WithMaxBatchSize(2),
+					WithScheduler(AnyOf(scheduler, NewTimeWindowScheduler(time.Hour))),
+					WithMetricSet(metrics),
+				)
+
+				action.EXPECT().Perform(gomock.Any(), []string{"foo", "bar"}).Times(1).Return([]Response[string]{{Response: "foo"}, {Response: "bar"}})
+			})
+
+			It("should forward observations and export window", func() {
+				Expect(testutil.ToFloat64(metrics.SchedulerWindowGauge)).To(Equal(0.5))
+
+				first := b.Do(ctx, "foo")
+				second := b.Do(ctx, "bar")
+				Expect(first.Await(ctx)).To(Equal("foo"))
+				Expect(second.Await(ctx)).To(Equal("bar"))
+
+				Eventually(scheduler.Window).ShouldNot(Equal(500 * time.Millisecond))
+				Eventually(func() float64 {
+					return testutil.ToFloat64(metrics.SchedulerWindowGauge)
+				}).Should(Equal(scheduler.Window().Seconds()))
+			})
+		})
+
+		Describe("can dispatch when capacity is idle", func() {
+			var (
+				performing chan struct{}
+				cc         NonBlockingConcurrencyControl
+			)
+
+			BeforeEach(func() {
+				performing = make(chan struct{})
+				cc = NewLimitedConcurrencyControl(1).(NonBlockingConcurrencyControl)
+				options = append(options,
+					WithMaxBatchSize(10),
+					WithConcurrencyControl(cc),
+					WithScheduler(NewIdleCapacityScheduler(cc)),
+				)
+
+				gomock.InOrder(
+					action.EXPECT().Perform(gomock.Any(), []string{"foo"}).Times(1).DoAndReturn(func(ctx context.Context, reqs []string) []Response[string] {
+						<-performing
+						return []Response[string]{{Response: "foo"}}
+					}),
+					action.EXPECT().Perform(gomock.Any(), []string{"bar", "baz"}).Times(1).Return([]Response[string]{
+						{Response: "bar"},
+						{Response: "baz"},
+					}),
+				)
+			})
+
+			It("should batch requests while capacity is busy", func() {
+				first := b.Do(ctx, "foo")
+				Eventually(cc.Available).Should(Equal(0))
+
+				second := b.Do(ctx, "bar")
+				third := b.Do(ctx, "baz")
+				close(performing)
+
+				Expect(first.Await(ctx)).To(Equal("foo"))
+				Expect(second.Await(ctx)).To(Equal("bar"))
+				Expect(third.Await(ctx)).To(Equal("baz"))
+			})
+		})
+
+		Describe("can schedule without goroutine per batch", func() {
+			var keys []string
+
+			BeforeEach(func() {
+				keys = []string{"foo", "bar", "baz"}
+				options = append(options, WithMaxBatchSize(2), WithScheduler(NewTimerHeapScheduler(time.Hour)))
+
+				for _, key := range keys {
+					action.EXPECT().Perform(gomock.Any(), []string{key}).Times(1).Return([]Response[string]{{Response: key}})
+				}
+				action.EXPECT().Perform(gomock.Any(), []string{"qux", "qux"}).Times(1).Return([]Response[string]{{Response: "qux"}, {Response: "qux"}})
+			})
+
+			It("should dispatch full batches and flush the others on shutdown", func() {
+				full := []Thunk[string]{
+					b.Do(ContextWithPartitionKey(ctx, "qux"), "qux"),
+					b.Do(ContextWithPartitionKey(ctx, "qux"), "qux"),
+				}
+				for _, thunk := range full {
+					Expect(thunk.Await(ctx)).To(Equal("qux"))
+				}
+
+				thunks := map[string]Thunk[string]{}
+				for _, key := range keys {
+					thunks[key] = b.Do(ContextWithPartitionKey(ctx, key), key)
+				}
+				Expect(b.Shutdown()).To(Succeed())
+				for _, key := range keys {
+					Expect(thunks[key].Await(ctx)).To(Equal(key))
+				}
+			})
+		})
+
 		Describe("should failed if already shutdown", func() {
 			It("should failed if already shutdown", func() {
 				b.Shutdown()
@@ -0,0 +1,232 @@
+package batcher
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ConcurrencyBudget is a pool of concurrency tokens shared by the controls it creates, so that the tokens
+// held across all of them never exceed its size. Each control has a reservation that only it can use,
+// and borrows from the unreserved part of the budget beyond it. Borrowers are served first come first served.
+type ConcurrencyBudget struct {
+	mu       sync.Mutex
+	size     int
+	reserved int
+	borrowed int
+	controls map[string]*budgetConcurrencyControl
+	waiters  list.List
+	released signal
+}
+
+// BudgetUsage is a snapshot of the tokens of a control of a ConcurrencyBudget.
+type BudgetUsage struct {
+	// Reserved is how many tokens are set aside for the control.
+	Reserved int
+	// InUse is how many tokens the control holds, including borrowed ones.
+	InUse int
+	// Borrowed is how many of the tokens in use are borrowed from the unreserved part of the budget.
+	Borrowed int
+	// Waiting is how many callers wait for a token of the control.
+	Waiting int
+}
+
+// budgetConcurrencyControl is a ConcurrencyControl that takes its tokens from a ConcurrencyBudget.
+type budgetConcurrencyControl struct {
+	budget   *ConcurrencyBudget
+	name     string
+	reserved int
+	used     int
+	waiting  int
+}
+
+// budgetWaiter is a caller waiting for a token of the budget.
+type budgetWaiter struct {
+	control *budgetConcurrencyControl
+	ready   chan struct{}
+}
+
+// NewConcurrencyBudget creates a new ConcurrencyBudget of size tokens.
+func NewConcurrencyBudget(size int) *ConcurrencyBudget {
+	return &ConcurrencyBudget{
+		size:     size,
+		controls: map[string]*budgetConcurrencyControl{},
+	}
+}
+
+// Control creates a ConcurrencyControl named name that draws from the budget, with reserved tokens set aside for it.
+// It fails with ErrBudgetOverReserved if the reservations of all controls would exceed the budget size.
+func (b *ConcurrencyBudget) Control(name string, reserved int) (ConcurrencyControl, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.controls[name]; ok {
+		return nil, fmt.Errorf("batcher: concurrency budget already has a control named %q", name)
+	}
+	if b.reserved+reserved > b.size {
+		return nil, ErrBudgetOverReserved
+	}
+
+	cc := &budgetConcurrencyControl{
+		budget:   b,
+		name:     name,
+		reserved: reserved,
+	}
+	b.controls[name] = cc
+	b.reserved += reserved
+	return cc, nil
+}
+
+// Usage returns a snapshot of the tokens of every control, by name.
+func (b *ConcurrencyBudget) Usage() map[string]BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	usage := make(map[string]BudgetUsage, len(b.controls))
+	for name, cc := range b.controls {
+		usage[name] = BudgetUsage{
+			Reserved: cc.reserved,
+			InUse:    cc.used,
+			Borrowed: max(0, cc.used-cc.reserved),
+			Waiting:  cc.waiting,
+		}
+	}
+	return usage
+}
+
+// Acquire acquires a concurrency token from the reservation of the control, or borrows one from the budget.
+func (c *budgetConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	b := c.budget
+	b.mu.Lock()
+	if c.canTake() {
+		c.take()
+		b.mu.Unlock()
+		return NewConcurrencyToken(c.release), nil
+	}
+
+	waiter := &budgetWaiter{control: c, ready: make(chan struct{})}
+	elem := b.waiters.PushBack(waiter)
+	c.waiting++
+	b.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return NewConcurrencyToken(c.release), nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		select {
+		case <-waiter.ready:
+			// Acquired while giving up, hand the token to the next waiters.
+			c.give()
+		default:
+			b.waiters.Remove(elem)
+			c.waiting--
+		}
+		// Giving up may free capacity, for the token handed back or for callers no longer queued behind.
+		b.notifyWaiters()
+		b.released.fire()
+		return nil, ctx.Err()
+	}
+}
+
+// TryAcquire acquires a concurrency token if one is available right now.
+func (c *budgetConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	c.budget.mu.Lock()
+	defer c.budget.mu.Unlock()
+
+	if !c.canTake() {
+		return nil, false
+	}
+	c.take()
+	return NewConcurrencyToken(c.release), true
+}
+
+// Available returns how many concurrency tokens could be acquired right now.
+func (c *budgetConcurrencyControl) Available() int {
+	b := c.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	available := max(0, c.reserved-c.used)
+	if b.waiters.Len() == 0 {
+		available += b.size - b.reserved - b.borrowed
+	}
+	return available
+}
+
+// Released returns a channel that is closed the next time any control of the budget releases a token.
+func (c *budgetConcurrencyControl) Released() <-chan struct{} {
+	c.budget.mu.Lock()
+	defer c.budget.mu.Unlock()
+
+	return c.budget.released.wait()
+}
+
+// canTake returns whether a token can be taken without waiting, it must be called with the budget lock held.
+// A token within the reservation is always free, a borrowed one must not jump ahead of the queued borrowers.
+func (c *budgetConcurrencyControl) canTake() bool {
+	return c.used < c.reserved || (c.budget.waiters.Len() == 0 && c.budget.canBorrow())
+}
+
+// take takes a token, it must be called with the budget lock held.
+func (c *budgetConcurrencyControl) take() {
+	if c.used >= c.reserved {
+		c.budget.borrowed++
+	}
+	c.used++
+}
+
+// give gives a token back, it must be called with the budget lock held.
+func (c *budgetConcurrencyControl) give() {
+	c.used--
+	if c.used >= c.reserved {
+		c.budget.borrowed--
+	}
+}
+
+// release gives a token back and hands it to the waiters.
+func (c *budgetConcurrencyControl) release() {
+	b := c.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c.give()
+	b.notifyWaiters()
+	b.released.fire()
+}
+
+// canBorrow returns whether the unreserved part of the budget has a token left, it must be called with the lock held.
+func (b *ConcurrencyBudget) canBorrow() bool {
+	return b.borrowed < b.size-b.reserved
+}
+
+// notifyWaiters hands tokens to the waiters in queue order, it must be called with the lock held.
+// Waiters within their reservation are served even when a borrower ahead of them has to keep waiting.
+func (b *ConcurrencyBudget) notifyWaiters() {
+	blocked := false
+	for elem := b.waiters.Front(); elem != nil; {
+		next := elem.Next()
+		waiter := elem.Value.(*budgetWaiter)
+		cc := waiter.control
+
+		switch {
+		case cc.used < cc.reserved, !blocked && b.canBorrow():
+			cc.take()
+			cc.waiting--
+			b.waiters.Remove(elem)
+			close(waiter.ready)
+		default:
+			blocked = true
+		}
+		elem = next
+	}
+}
@@ -0,0 +1,206 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+)
+
+var _ = Describe("ConcurrencyBudget", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		budget *ConcurrencyBudget
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		budget = NewConcurrencyBudget(4)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	control := func(name string, reserved int) ConcurrencyControl {
+		cc, err := budget.Control(name, reserved)
+		Expect(err).Should(BeNil())
+		return cc
+	}
+
+	acquireN := func(cc ConcurrencyControl, n int) []ConcurrencyToken {
+		tokens := make([]ConcurrencyToken, n)
+		for i := range tokens {
+			token, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+			tokens[i] = token
+		}
+		return tokens
+	}
+
+	acquireAsync := func(cc ConcurrencyControl) chan ConcurrencyToken {
+		tokens := make(chan ConcurrencyToken, 1)
+		go func() {
+			token, err := cc.Acquire(ctx)
+			if err == nil {
+				tokens <- token
+			}
+		}()
+		return tokens
+	}
+
+	waiting := func(name string) func() int {
+		return func() int {
+			return budget.Usage()[name].Waiting
+		}
+	}
+
+	It("should reject reservations over size", func() {
+		control("foo", 3)
+		_, err := budget.Control("bar", 2)
+		Expect(err).Should(MatchError(ErrBudgetOverReserved))
+		_, err = budget.Control("foo", 1)
+		Expect(err).Should(HaveOccurred())
+		control("bar", 1)
+	})
+
+	It("should borrow beyond reservation", func() {
+		foo := control("foo", 1)
+		control("bar", 1)
+
+		acquireN(foo, 3)
+		Expect(budget.Usage()).Should(Equal(map[string]BudgetUsage{
+			"foo": {Reserved: 1, InUse: 3, Borrowed: 2},
+			"bar": {Reserved: 1},
+		}))
+		Expect(foo.(NonBlockingConcurrencyControl).Available()).Should(Equal(0))
+		_, ok := foo.(NonBlockingConcurrencyControl).TryAcquire()
+		Expect(ok).Should(BeFalse())
+	})
+
+	It("should guarantee reservation while others borrow", func() {
+		foo := control("foo", 1)
+		bar := control("bar", 1)
+		tokens := acquireN(foo, 3)
+
+		blocked := acquireAsync(foo)
+		Eventually(waiting("foo")).Should(Equal(1))
+		acquireN(bar, 1)
+		Expect(budget.Usage()["bar"].InUse).Should(Equal(1))
+
+		waited := acquireAsync(bar)
+		Eventually(waiting("bar")).Should(Equal(1))
+		Consistently(blocked).ShouldNot(Receive())
+
+		tokens[0].Release()
+		Eventually(blocked).Should(Receive())
+		Consistently(waited).ShouldNot(Receive())
+		Expect(budget.Usage()["foo"]).Should(Equal(BudgetUsage{Reserved: 1, InUse: 3, Borrowed: 2}))
+	})
+
+	It("should serve borrowers in order", func() {
+		foo := control("foo", 0)
+		bar := control("bar", 0)
+		baz := control("baz", 0)
+		tokens := acquireN(foo, 4)
+
+		first := acquireAsync(bar)
+		Eventually(waiting("bar")).Should(Equal(1))
+		second := acquireAsync(baz)
+		Eventually(waiting("baz")).Should(Equal(1))
+
+		_, ok := foo.(NonBlockingConcurrencyControl).TryAcquire()
+		Expect(ok).Should(BeFalse())
+
+		tokens[0].Release()
+		Eventually(first).Should(Receive())
+		Consistently(second).ShouldNot(Receive())
+
+		tokens[1].Release()
+		Eventually(second).Should(Receive())
+	})
+
+	It("should remove cancelled waiter", func() {
+		foo := control("foo", 0)
+		tokens := acquireN(foo, 4)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := foo.Acquire(timeoutCtx)
+		Expect(err).Should(MatchError(context.DeadlineExceeded))
+		Expect(budget.Usage()["foo"].Waiting).Should(Equal(0))
+
+		released := foo.(NonBlockingConcurrencyControl).Released()
+		tokens[0].Release()
+		Eventually(released).Should(BeClosed())
+		Expect(foo.(NonBlockingConcurrencyControl).Available()).Should(Equal(1))
+	})
+
+	It("should signal when cancelled waiter gives up", func() {
+		foo := control("foo", 0).(NonBlockingConcurrencyControl)
+		tokens := acquireN(foo, 4)
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := foo.Acquire(waitCtx)
+			errs <- err
+		}()
+		Eventually(waiting("foo")).Should(Equal(1))
+
+		released := foo.Released()
+		cancel()
+		Eventually(errs).Should(Receive(MatchError(context.Canceled)))
+		Eventually(released).Should(BeClosed())
+
+		for _, token := range tokens {
+			token.Release()
+		}
+	})
+
+	It("should never exceed size", func() {
+		controls := []ConcurrencyControl{control("foo", 1), control("bar", 2), control("baz", 0)}
+
+		var inflight, peak int64
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(cc ConcurrencyControl) {
+				defer wg.Done()
+				token, err := cc.Acquire(ctx)
+				Expect(err).Should(BeNil())
+
+				current := atomic.AddInt64(&inflight, 1)
+				for {
+					old := atomic.LoadInt64(&peak)
+					if current <= old || atomic.CompareAndSwapInt64(&peak, old, current) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt64(&inflight, -1)
+				token.Release()
+			}(controls[gofakeit.Number(0, len(controls)-1)])
+		}
+		wg.Wait()
+
+		Expect(atomic.LoadInt64(&peak)).Should(BeNumerically("<=", 4))
+		for _, usage := range budget.Usage() {
+			Expect(usage.InUse).Should(Equal(0))
+		}
+	})
+})
@@ -0,0 +1,292 @@
+package batcher
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every batch through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every batch with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of small probe batches through.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops batches from reaching a failing downstream.
+// It opens when the ratio of failed batches among the recent ones reaches the failure ratio,
+// rejects batches while open, and after the open timeout lets small probe batches through
+// to decide whether to close again.
+// A batch is failed if it could not be performed, or if every one of its responses is an error.
+type CircuitBreaker struct {
+	mu    sync.Mutex
+	clock clock.PassiveClock
+
+	failureRatio float64
+	windowSize   int
+	minBatches   int
+	openTimeout  time.Duration
+	maxProbes    int
+	probeSize    int
+	listeners    []*circuitListener
+
+	state     CircuitState
+	openedAt  time.Time
+	outcomes  []bool
+	failures  int
+	probes    int
+	successes int
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker with the provided options.
+func NewCircuitBreaker(options ...circuitBreakerOption) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		clock:        clock.RealClock{},
+		failureRatio: 0.5,
+		windowSize:   20,
+		minBatches:   10,
+		openTimeout:  5 * time.Second,
+		maxProbes:    1,
+		probeSize:    1,
+	}
+
+	for _, opt := range options {
+		opt(cb)
+	}
+
+	return cb
+}
+
+// State returns the current state of the circuit breaker.
+func (c *CircuitBreaker) State() CircuitState {
+	c.mu.Lock()
+	notify := c.refresh()
+	state := c.state
+	c.mu.Unlock()
+
+	notify()
+	return state
+}
+
+// allow decides whether a batch of size items may be performed.
+// It returns how many of the items may be performed and whether the batch is a half-open probe.
+func (c *CircuitBreaker) allow(size int) (int, bool, error) {
+	c.mu.Lock()
+	notify := c.refresh()
+	defer notify()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		return 0, false, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if c.probes >= c.maxProbes {
+			return 0, false, ErrCircuitOpen
+		}
+		c.probes++
+		if size > c.probeSize {
+			size = c.probeSize
+		}
+		return size, true, nil
+	default:
+		return size, false, nil
+	}
+}
+
+// record records the outcome of a batch that was allowed.
+func (c *CircuitBreaker) record(probe bool, failed bool) {
+	c.mu.Lock()
+	notify := func() {}
+	defer func() { notify() }()
+	defer c.mu.Unlock()
+
+	if probe {
+		c.probes--
+		if c.state != CircuitHalfOpen {
+			return
+		}
+		if failed {
+			notify = c.transition(CircuitOpen)
+			return
+		}
+		c.successes++
+		if c.successes >= c.maxProbes {
+			notify = c.transition(CircuitClosed)
+		}
+		return
+	}
+
+	if c.state != CircuitClosed {
+		return
+	}
+
+	if len(c.outcomes) >= c.windowSize {
+		if c.outcomes[0] {
+			c.failures--
+		}
+		c.outcomes = c.outcomes[1:]
+	}
+	c.outcomes = append(c.outcomes, failed)
+	if failed {
+		c.failures++
+	}
+
+	if len(c.outcomes) >= c.minBatches && float64(c.failures) >= c.failureRatio*float64(len(c.outcomes)) {
+		notify = c.transition(CircuitOpen)
+	}
+}
+
+// abort gives back a batch that was allowed but not performed.
+func (c *CircuitBreaker) abort(probe bool) {
+	if !probe {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probes--
+}
+
+// refresh moves an open circuit to half-open once the open timeout has passed.
+// It must be called with the lock held, and the returned function must be called after unlocking.
+func (c *CircuitBreaker) refresh() func() {
+	if c.state == CircuitOpen && c.clock.Since(c.openedAt) >= c.openTimeout {
+		return c.transition(CircuitHalfOpen)
+	}
+	return func() {}
+}
+
+// transition moves the circuit to the state and resets the counters.
+// It must be called with the lock held, and the returned function, which notifies the listeners,
+// must be called after unlocking.
+func (c *CircuitBreaker) transition(to CircuitState) func() {
+	from := c.state
+	c.state = to
+	c.outcomes = nil
+	c.failures = 0
+	c.successes = 0
+	if to == CircuitOpen {
+		c.openedAt = c.clock.Now()
+	}
+
+	listeners := c.listeners
+	return func() {
+		for _, listener := range listeners {
+			listener.notify(from, to)
+		}
+	}
+}
+
+// circuitListener is a function called on every state transition.
+type circuitListener struct {
+	notify func(from, to CircuitState)
+}
+
+// addListener adds a function that is called on every state transition, and returns a function that removes it.
+func (c *CircuitBreaker) addListener(listener func(from, to CircuitState)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	added := &circuitListener{notify: listener}
+	c.listeners = append(c.listeners, added)
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// Build a new slice, transitions being notified may still hold the old one.
+		listeners := make([]*circuitListener, 0, len(c.listeners))
+		for _, l := range c.listeners {
+			if l != added {
+				listeners = append(listeners, l)
+			}
+		}
+		c.listeners = listeners
+	}
+}
+
+// batchError returns the error a batch counts as failed with, or nil if it did not fail.
+// A batch fails if it could not be performed, or if every one of its responses is an error.
+func batchError[RES any](results []Response[RES], err error) error {
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	for _, res := range results {
+		if res.Error == nil {
+			return nil
+		}
+	}
+	return results[0].Error
+}
+
+// circuitBreakerOption is a function that configures a CircuitBreaker.
+type circuitBreakerOption func(*CircuitBreaker)
+
+// WithCircuitBreakerFailureRatio returns an option that sets the ratio of failed batches that opens the circuit.
+func WithCircuitBreakerFailureRatio(ratio float64) circuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.failureRatio = ratio
+	}
+}
+
+// WithCircuitBreakerWindow returns an option that sets how many recent batches the failure ratio is computed over,
+// and how many of them must have completed before the circuit can open.
+func WithCircuitBreakerWindow(size int, minBatches int) circuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.windowSize = size
+		c.minBatches = minBatches
+	}
+}
+
+// WithCircuitBreakerOpenTimeout returns an option that sets how long the circuit stays open before it turns half-open.
+func WithCircuitBreakerOpenTimeout(timeout time.Duration) circuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.openTimeout = timeout
+	}
+}
+
+// WithCircuitBreakerHalfOpenProbes returns an option that sets how many probe batches are let through while half-open,
+// and the maximum number of items of a probe batch. Items over the size are rejected with ErrCircuitOpen.
+// The circuit closes once that many probes succeed.
+func WithCircuitBreakerHalfOpenProbes(probes int, size int) circuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.maxProbes = probes
+		c.probeSize = size
+	}
+}
+
+// WithCircuitBreakerStateChangeCallback returns an option that sets a function called on every state transition.
+func WithCircuitBreakerStateChangeCallback(callback func(from, to CircuitState)) circuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.listeners = append(c.listeners, &circuitListener{notify: callback})
+	}
+}
+
+// WithCircuitBreakerClock returns an option that sets the clock of a CircuitBreaker.
+func WithCircuitBreakerClock(clock clock.PassiveClock) circuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,115 @@
+package batcher
+
+import (
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	var (
+		fakeClock   *clocktesting.FakePassiveClock
+		openTimeout time.Duration
+		windowSize  int
+		probeSize   int
+		transitions [][2]CircuitState
+
+		cb *CircuitBreaker
+	)
+
+	BeforeEach(func() {
+		fakeClock = clocktesting.NewFakePassiveClock(time.Now())
+		openTimeout = time.Duration(gofakeit.Number(1, 10)) * time.Second
+		windowSize = gofakeit.Number(4, 10)
+		probeSize = gofakeit.Number(2, 5)
+		transitions = nil
+
+		cb = NewCircuitBreaker(
+			WithCircuitBreakerClock(fakeClock),
+			WithCircuitBreakerFailureRatio(0.5),
+			WithCircuitBreakerWindow(windowSize, windowSize),
+			WithCircuitBreakerOpenTimeout(openTimeout),
+			WithCircuitBreakerHalfOpenProbes(1, probeSize),
+			WithCircuitBreakerStateChangeCallback(func(from, to CircuitState) {
+				transitions = append(transitions, [2]CircuitState{from, to})
+			}),
+		)
+	})
+
+	open := func() {
+		for i := 0; i < windowSize; i++ {
+			_, probe, err := cb.allow(1)
+			Expect(err).To(BeNil())
+			cb.record(probe, i%2 == 0)
+		}
+		Expect(cb.State()).To(Equal(CircuitOpen))
+	}
+
+	It("should stay closed below failure ratio", func() {
+		for i := 0; i < windowSize*2; i++ {
+			allowed, probe, err := cb.allow(10)
+			Expect(err).To(BeNil())
+			Expect(probe).To(BeFalse())
+			Expect(allowed).To(Equal(10))
+			cb.record(probe, i%4 == 0)
+		}
+		Expect(cb.State()).To(Equal(CircuitClosed))
+		Expect(transitions).To(BeEmpty())
+	})
+
+	It("should open when failure ratio is reached", func() {
+		open()
+
+		_, _, err := cb.allow(1)
+		Expect(err).To(MatchError(ErrCircuitOpen))
+		Expect(transitions).To(Equal([][2]CircuitState{{CircuitClosed, CircuitOpen}}))
+	})
+
+	It("should let a small probe through after open timeout and close on success", func() {
+		open()
+		fakeClock.SetTime(fakeClock.Now().Add(openTimeout))
+
+		allowed, probe, err := cb.allow(probeSize * 2)
+		Expect(err).To(BeNil())
+		Expect(probe).To(BeTrue())
+		Expect(allowed).To(Equal(probeSize))
+		Expect(cb.State()).To(Equal(CircuitHalfOpen))
+
+		_, _, err = cb.allow(1)
+		Expect(err).To(MatchError(ErrCircuitOpen))
+
+		cb.record(probe, false)
+		Expect(cb.State()).To(Equal(CircuitClosed))
+		Expect(transitions).To(Equal([][2]CircuitState{
+			{CircuitClosed, CircuitOpen},
+			{CircuitOpen, CircuitHalfOpen},
+			{CircuitHalfOpen, CircuitClosed},
+		}))
+	})
+
+	It("should open again if probe failed", func() {
+		open()
+		fakeClock.SetTime(fakeClock.Now().Add(openTimeout))
+
+		_, probe, err := cb.allow(1)
+		Expect(err).To(BeNil())
+		cb.record(probe, true)
+		Expect(cb.State()).To(Equal(CircuitOpen))
+	})
+
+	It("should give back probe if aborted", func() {
+		open()
+		fakeClock.SetTime(fakeClock.Now().Add(openTimeout))
+
+		_, probe, err := cb.allow(1)
+		Expect(err).To(BeNil())
+		cb.abort(probe)
+
+		_, probe, err = cb.allow(1)
+		Expect(err).To(BeNil())
+		Expect(probe).To(BeTrue())
+	})
+})
@@ -0,0 +1,193 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+)
+
+// anyOfScheduler is a Scheduler that dispatches batches as soon as any of its schedulers would.
+type anyOfScheduler struct {
+	schedulers []Scheduler
+}
+
+// AnyOf creates a new Scheduler that dispatches a batch as soon as any of the provided schedulers would,
+// and stops the others.
+func AnyOf(schedulers ...Scheduler) Scheduler {
+	return &anyOfScheduler{
+		schedulers: schedulers,
+	}
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (a *anyOfScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	fired, stop := startSchedulers(ctx, batch, a.schedulers)
+
+	select {
+	case <-ctx.Done():
+		stop()
+		return
+	case <-batch.Dispatch():
+	case <-batch.Full():
+	case <-fired:
+	}
+	stop()
+	callback.Call()
+}
+
+// allOfScheduler is a Scheduler that dispatches batches once all of its schedulers would.
+type allOfScheduler struct {
+	schedulers []Scheduler
+}
+
+// AllOf creates a new Scheduler that dispatches a batch once every one of the provided schedulers would.
+// A batch that is full or flushed on shutdown is dispatched right away.
+func AllOf(schedulers ...Scheduler) Scheduler {
+	return &allOfScheduler{
+		schedulers: schedulers,
+	}
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (a *allOfScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	fired, stop := startSchedulers(ctx, batch, a.schedulers)
+
+	for remaining := len(a.schedulers); remaining > 0; {
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+		case <-batch.Dispatch():
+			remaining = 0
+		case <-batch.Full():
+			remaining = 0
+		case <-fired:
+			remaining--
+		}
+	}
+	stop()
+	callback.Call()
+}
+
+// sequenceScheduler is a Scheduler that runs its schedulers one after another.
+type sequenceScheduler struct {
+	schedulers []Scheduler
+}
+
+// Sequence creates a new Scheduler that starts each of the provided schedulers once the previous one would
+// dispatch the batch, and dispatches it once the last one would. For example Sequence(minSize, timeWindow)
+// waits for the batch to reach a minimum size, then for a time window more.
+// A batch that is full or flushed on shutdown is dispatched right away.
+func Sequence(schedulers ...Scheduler) Scheduler {
+	return &sequenceScheduler{
+		schedulers: schedulers,
+	}
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (s *sequenceScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	for _, scheduler := range s.schedulers {
+		fired, stop := startSchedulers(ctx, batch, []Scheduler{scheduler})
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+		case <-batch.Dispatch():
+			stop()
+			callback.Call()
+			return
+		case <-batch.Full():
+			stop()
+			callback.Call()
+			return
+		case <-fired:
+			stop()
+		}
+	}
+	callback.Call()
+}
+
+// Observe passes the observation to the schedulers that observe batches.
+func (a *anyOfScheduler) Observe(observation BatchObservation) {
+	observeSchedulers(a.schedulers, observation)
+}
+
+// Observe passes the observation to the schedulers that observe batches.
+func (a *allOfScheduler) Observe(observation BatchObservation) {
+	observeSchedulers(a.schedulers, observation)
+}
+
+// Observe passes the observation to the schedulers that observe batches.
+func (s *sequenceScheduler) Observe(observation BatchObservation) {
+	observeSchedulers(s.schedulers, observation)
+}
+
+// children returns the schedulers the scheduler is built from.
+func (a *anyOfScheduler) children() []Scheduler {
+	return a.schedulers
+}
+
+// children returns the schedulers the scheduler is built from.
+func (a *allOfScheduler) children() []Scheduler {
+	return a.schedulers
+}
+
+// children returns the schedulers the scheduler is built from.
+func (s *sequenceScheduler) children() []Scheduler {
+	return s.schedulers
+}
+
+// compositeScheduler is a Scheduler built from other schedulers.
+type compositeScheduler interface {
+	children() []Scheduler
+}
+
+// observeSchedulers passes the observation to every scheduler that observes batches.
+func observeSchedulers(schedulers []Scheduler, observation BatchObservation) {
+	for _, scheduler := range schedulers {
+		if observing, ok := scheduler.(ObservingScheduler); ok {
+			observing.Observe(observation)
+		}
+	}
+}
+
+// findWindowScheduler returns the first scheduler that reports its window, looking into composite schedulers.
+func findWindowScheduler(scheduler Scheduler) (windowScheduler, bool) {
+	if window, ok := scheduler.(windowScheduler); ok {
+		return window, true
+	}
+	if composite, ok := scheduler.(compositeScheduler); ok {
+		for _, child := range composite.children() {
+			if window, ok := findWindowScheduler(child); ok {
+				return window, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// startSchedulers schedules the batch on every scheduler under a child context of ctx.
+// The returned channel receives once for every scheduler that calls its callback, however many times it does.
+// The returned function cancels the schedulers that are still running and waits for them to return.
+func startSchedulers(ctx context.Context, batch Batch, schedulers []Scheduler) (<-chan struct{}, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	fired := make(chan struct{}, len(schedulers))
+	wg := &sync.WaitGroup{}
+
+	for _, scheduler := range schedulers {
+		once := &sync.Once{}
+		wg.Add(1)
+		go func(scheduler Scheduler) {
+			defer wg.Done()
+			scheduler.Schedule(ctx, batch, NewSchedulerCallback(func() {
+				once.Do(func() {
+					fired <- struct{}{}
+				})
+			}))
+		}(scheduler)
+	}
+
+	return fired, func() {
+		cancel()
+		wg.Wait()
+	}
+}
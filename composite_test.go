@@ -0,0 +1,208 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+)
+
+// TestChildScheduler is a Scheduler that calls the callback twice when triggered,
+// and records when it is started and stopped.
+type TestChildScheduler struct {
+	trigger chan struct{}
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func NewTestChildScheduler() *TestChildScheduler {
+	return &TestChildScheduler{
+		trigger: make(chan struct{}),
+		started: make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (t *TestChildScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	close(t.started)
+	select {
+	case <-ctx.Done():
+		close(t.stopped)
+	case <-t.trigger:
+		callback.Call()
+		callback.Call()
+	}
+}
+
+var _ = Describe("CompositeScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		mockBatch    *MockBatch
+		mockCallback *MockSchedulerCallback
+
+		dispatchTrigger chan struct{}
+		fullTrigger     chan struct{}
+		called          chan struct{}
+		returned        chan struct{}
+
+		first  *TestChildScheduler
+		second *TestChildScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+
+		dispatchTrigger = make(chan struct{})
+		fullTrigger = make(chan struct{})
+		called = make(chan struct{})
+		returned = make(chan struct{})
+
+		mockBatch = NewMockBatch(ctrl)
+		mockBatch.EXPECT().Dispatch().Return(dispatchTrigger).AnyTimes()
+		mockBatch.EXPECT().Full().Return(fullTrigger).AnyTimes()
+
+		mockCallback = NewMockSchedulerCallback(ctrl)
+
+		first = NewTestChildScheduler()
+		second = NewTestChildScheduler()
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+		Eventually(returned).Should(BeClosed())
+	})
+
+	schedule := func(scheduler Scheduler) {
+		go func() {
+			defer close(returned)
+			scheduler.Schedule(ctx, mockBatch, mockCallback)
+		}()
+	}
+
+	expectCall := func() {
+		mockCallback.EXPECT().Call().Times(1).Do(func() {
+			close(called)
+		})
+	}
+
+	Describe("AnyOf", func() {
+		It("should call callback once any scheduler fires", func() {
+			expectCall()
+			schedule(AnyOf(first, second))
+			Eventually(first.started).Should(BeClosed())
+			Eventually(second.started).Should(BeClosed())
+
+			close(second.trigger)
+			Eventually(called).Should(BeClosed())
+			Expect(first.stopped).Should(BeClosed())
+			Eventually(returned).Should(BeClosed())
+		})
+
+		It("should call callback if dispatch triggered", func() {
+			expectCall()
+			schedule(AnyOf(first, second))
+			close(dispatchTrigger)
+			Eventually(called).Should(BeClosed())
+			Expect(first.stopped).Should(BeClosed())
+			Expect(second.stopped).Should(BeClosed())
+		})
+
+		It("should stop schedulers if context is done", func() {
+			mockCallback.EXPECT().Call().Times(0)
+			schedule(AnyOf(first, second))
+			cancelFunc()
+			Eventually(returned).Should(BeClosed())
+			Expect(first.stopped).Should(BeClosed())
+			Expect(second.stopped).Should(BeClosed())
+		})
+	})
+
+	Describe("AllOf", func() {
+		It("should call callback once every scheduler fires", func() {
+			expectCall()
+			schedule(AllOf(first, second))
+
+			close(first.trigger)
+			Consistently(called).ShouldNot(BeClosed())
+
+			close(second.trigger)
+			Eventually(called).Should(BeClosed())
+			Eventually(returned).Should(BeClosed())
+		})
+
+		It("should call callback if full triggered", func() {
+			expectCall()
+			schedule(AllOf(first, second))
+			close(first.trigger)
+			close(fullTrigger)
+			Eventually(called).Should(BeClosed())
+			Expect(second.stopped).Should(BeClosed())
+		})
+
+		It("should call callback instantly without schedulers", func() {
+			expectCall()
+			schedule(AllOf())
+			Eventually(called).Should(BeClosed())
+		})
+	})
+
+	Describe("Sequence", func() {
+		It("should start schedulers one after another", func() {
+			expectCall()
+			schedule(Sequence(first, second))
+			Eventually(first.started).Should(BeClosed())
+			Consistently(second.started).ShouldNot(BeClosed())
+
+			close(first.trigger)
+			Eventually(second.started).Should(BeClosed())
+			Consistently(called).ShouldNot(BeClosed())
+
+			close(second.trigger)
+			Eventually(called).Should(BeClosed())
+		})
+
+		It("should call callback if dispatch triggered", func() {
+			expectCall()
+			schedule(Sequence(first, second))
+			Eventually(first.started).Should(BeClosed())
+			close(dispatchTrigger)
+			Eventually(called).Should(BeClosed())
+			Expect(first.stopped).Should(BeClosed())
+			Expect(second.started).ShouldNot(BeClosed())
+		})
+	})
+})
+
+var _ = Describe("CompositeScheduler observation", func() {
+	It("should forward observations to observing schedulers", func() {
+		observation := BatchObservation{Size: 3, Wait: time.Second, Perform: time.Millisecond}
+		for _, compose := range []func(...Scheduler) Scheduler{AnyOf, AllOf, Sequence} {
+			observing := NewMockObservingScheduler(ctrl)
+			observing.EXPECT().Observe(observation).Times(1)
+
+			composite := compose(NewTestChildScheduler(), observing)
+			composite.(ObservingScheduler).Observe(observation)
+		}
+	})
+
+	It("should find window of scheduler inside composite", func() {
+		slo := NewLatencySLOScheduler(time.Second).(*LatencySLOScheduler)
+		window, ok := findWindowScheduler(Sequence(NewTestChildScheduler(), AnyOf(NewTestChildScheduler(), slo)))
+		Expect(ok).Should(BeTrue())
+		Expect(window).Should(BeIdenticalTo(slo))
+
+		_, ok = findWindowScheduler(AllOf(NewTestChildScheduler()))
+		Expect(ok).Should(BeFalse())
+	})
+})
@@ -2,6 +2,11 @@ package batcher
 
 import (
 	"context"
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
 )
 
 // ConcurrencyControl is an interface for controlling the concurrency of batch operations.
@@ -10,20 +15,56 @@ type ConcurrencyControl interface {
 	Acquire(ctx context.Context) (ConcurrencyToken, error)
 }
 
+// WeightedConcurrencyControl is a ConcurrencyControl whose tokens can weigh more than one.
+// The batcher acquires tokens weighing the number of items in the batch,
+// so it limits the number of items in flight instead of the number of batches.
+type WeightedConcurrencyControl interface {
+	ConcurrencyControl
+	// AcquireN acquires a concurrency token weighing n.
+	AcquireN(ctx context.Context, n int) (ConcurrencyToken, error)
+}
+
+// NonBlockingConcurrencyControl is a ConcurrencyControl that can be queried for capacity without blocking.
+// Custom schedulers can use it to dispatch eagerly while capacity is idle.
+type NonBlockingConcurrencyControl interface {
+	ConcurrencyControl
+	// TryAcquire acquires a concurrency token if one is available right now.
+	TryAcquire() (ConcurrencyToken, bool)
+	// Available returns how many concurrency tokens could be acquired right now.
+	Available() int
+	// Released returns a channel that is closed the next time capacity frees up.
+	Released() <-chan struct{}
+}
+
 // ConcurrencyToken is an interface for a token that controls concurrency.
 type ConcurrencyToken interface {
 	// Release releases the concurrency token.
 	Release()
 }
 
-// concurrencyToken is an implementation of the ConcurrencyToken interface.
+// FeedbackConcurrencyToken is a ConcurrencyToken that can be told how the work it guarded went.
+// The batcher releases tokens implementing it with the error of the batch, so adaptive controls can learn from it.
+type FeedbackConcurrencyToken interface {
+	ConcurrencyToken
+	// ReleaseWithError releases the concurrency token, err is the error of the guarded work or nil.
+	ReleaseWithError(err error)
+}
+
+// concurrencyToken is an implementation of the FeedbackConcurrencyToken interface.
 type concurrencyToken struct {
 	released chan bool
-	release  func()
+	release  func(error)
 }
 
 // NewConcurrencyToken creates a new ConcurrencyToken with the provided release function.
 func NewConcurrencyToken(release func()) ConcurrencyToken {
+	return NewFeedbackConcurrencyToken(func(error) {
+		release()
+	})
+}
+
+// NewFeedbackConcurrencyToken creates a new FeedbackConcurrencyToken with the provided release function.
+func NewFeedbackConcurrencyToken(release func(err error)) FeedbackConcurrencyToken {
 	token := &concurrencyToken{
 		released: make(chan bool, 1),
 		release:  release,
@@ -34,10 +75,30 @@ func NewConcurrencyToken(release func()) ConcurrencyToken {
 
 // Release releases the concurrency token.
 func (c *concurrencyToken) Release() {
+	c.ReleaseWithError(nil)
+}
+
+// ReleaseWithError releases the concurrency token with the error of the guarded work.
+func (c *concurrencyToken) ReleaseWithError(err error) {
 	if !<-c.released {
-		c.release()
-		c.released <- true
+		c.release(err)
+	}
+	c.released <- true
+}
+
+// releaseToken releases the token, passing err along if the token takes feedback.
+func releaseToken(token ConcurrencyToken, err error) {
+	if feedback, ok := token.(FeedbackConcurrencyToken); ok {
+		feedback.ReleaseWithError(err)
+		return
 	}
+	token.Release()
+}
+
+// concurrencyLimiter is implemented by controls that can report their current concurrency limit.
+type concurrencyLimiter interface {
+	// Limit returns the current concurrency limit.
+	Limit() int
 }
 
 // unlimitedConcurrencyControl is a ConcurrencyControl that allows unlimited concurrency.
@@ -53,64 +114,352 @@ func (u unlimitedConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyTo
 	return NewConcurrencyToken(func() {}), nil
 }
 
+// TryAcquire acquires a concurrency token, which is always available.
+func (u unlimitedConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	return NewConcurrencyToken(func() {}), true
+}
+
+// Available returns the largest int, since capacity is unlimited.
+func (u unlimitedConcurrencyControl) Available() int {
+	return math.MaxInt
+}
+
+// Released returns a nil channel, since capacity never needs to free up.
+func (u unlimitedConcurrencyControl) Released() <-chan struct{} {
+	return nil
+}
+
 // limitedConcurrencyControl is a ConcurrencyControl that limits concurrency.
+// Callers waiting for a token are served in strict FIFO order, and a caller that gives up on its context
+// leaves the queue.
 type limitedConcurrencyControl struct {
-	sem   chan struct{}
-	queue chan chan struct{}
+	sem         *semaphore
+	concurrency int
+	clock       clock.Clock
+
+	mu     sync.Mutex
+	warmUp *warmUp
+	stop   chan struct{}
 }
 
 // NewLimitedConcurrencyControl creates a new limitedConcurrencyControl with the provided concurrency limit and options.
+// The waiter queue is unbounded unless WithLimitedConcurrencyControlQueueSize is set.
 func NewLimitedConcurrencyControl(concurrency int, option ...limitedConcurrencyControlOption) ConcurrencyControl {
 	cc := &limitedConcurrencyControl{
-		sem:   make(chan struct{}, concurrency),
-		queue: make(chan chan struct{}, concurrency*2),
+		sem:         newSemaphore(concurrency),
+		concurrency: concurrency,
+		clock:       clock.RealClock{},
 	}
 
 	for _, opt := range option {
 		opt(cc)
 	}
 
+	cc.WarmUp()
+
 	return cc
 }
 
 // Acquire acquires a concurrency token.
 func (l *limitedConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-		select {
-		case l.sem <- struct{}{}:
-			return NewConcurrencyToken(l.release), nil
-		default:
-			releaseChan := make(chan struct{})
-			l.queue <- releaseChan
+	if err := l.sem.acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return NewConcurrencyToken(l.release), nil
+}
+
+// TryAcquire acquires a concurrency token if one is available right now.
+func (l *limitedConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	if !l.sem.tryAcquire(1) {
+		return nil, false
+	}
+	return NewConcurrencyToken(l.release), true
+}
+
+// Available returns how many concurrency tokens could be acquired right now.
+func (l *limitedConcurrencyControl) Available() int {
+	return l.sem.available()
+}
+
+// Released returns a channel that is closed the next time a token is released.
+func (l *limitedConcurrencyControl) Released() <-chan struct{} {
+	return l.sem.releasedSignal()
+}
+
+// Limit returns the current concurrency limit, which is below the configured one while warming up.
+func (l *limitedConcurrencyControl) Limit() int {
+	l.sem.mu.Lock()
+	defer l.sem.mu.Unlock()
+
+	return l.sem.limit
+}
+
+// WarmUp restarts the warm-up ramp from its floor, it does nothing without WithLimitedConcurrencyControlWarmUp.
+func (l *limitedConcurrencyControl) WarmUp() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.warmUp == nil {
+		return
+	}
+
+	if l.stop != nil {
+		close(l.stop)
+	}
+	l.stop = make(chan struct{})
+	l.sem.setLimit(l.warmUp.limitAt(0, l.concurrency))
+
+	go l.ramp(l.clock.Now(), l.stop)
+}
+
+// ramp raises the limit one step at a time until it reaches the concurrency or stop is closed.
+func (l *limitedConcurrencyControl) ramp(start time.Time, stop chan struct{}) {
+	for limit := l.warmUp.limitAt(0, l.concurrency) + 1; limit <= l.concurrency; limit++ {
+		if delay := l.warmUp.timeOf(limit, l.concurrency) - l.clock.Since(start); delay > 0 {
+			timer := l.clock.NewTimer(delay)
 			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-releaseChan:
-				return NewConcurrencyToken(l.release), nil
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C():
 			}
 		}
+
+		l.mu.Lock()
+		select {
+		case <-stop:
+			l.mu.Unlock()
+			return
+		default:
+		}
+		l.sem.setLimit(limit)
+		l.mu.Unlock()
 	}
 }
 
 // release releases a concurrency token.
 func (l *limitedConcurrencyControl) release() {
-	select {
-	case releaseChan := <-l.queue:
-		releaseChan <- struct{}{}
-	default:
-		<-l.sem
-	}
+	l.sem.release(1)
 }
 
 // LimitedConcurrencyControlOption is a function that configures a limitedConcurrencyControl.
 type limitedConcurrencyControlOption func(*limitedConcurrencyControl)
 
-// WithLimitedConcurrencyControlQueueSize returns an option that sets the queue size for a limitedConcurrencyControl.
+// WithLimitedConcurrencyControlWarmUp returns an option that ramps the limit of a limitedConcurrencyControl
+// from floor up to its concurrency over duration, starting when it is created and again on every WarmUp call.
+func WithLimitedConcurrencyControlWarmUp(duration time.Duration, floor int, mode WarmUpMode) limitedConcurrencyControlOption {
+	return func(l *limitedConcurrencyControl) {
+		l.warmUp = &warmUp{
+			duration: duration,
+			floor:    floor,
+			mode:     mode,
+		}
+	}
+}
+
+// WithLimitedConcurrencyControlClock returns an option that sets the clock for a limitedConcurrencyControl.
+func WithLimitedConcurrencyControlClock(clock clock.Clock) limitedConcurrencyControlOption {
+	return func(l *limitedConcurrencyControl) {
+		l.clock = clock
+	}
+}
+
+// WithLimitedConcurrencyControlQueueSize returns an option that bounds the waiter queue of a limitedConcurrencyControl.
+// Acquire fails with ErrQueueFull when size callers are already waiting.
 func WithLimitedConcurrencyControlQueueSize(size int) limitedConcurrencyControlOption {
 	return func(l *limitedConcurrencyControl) {
-		l.queue = make(chan chan struct{}, size)
+		l.sem.maxQueue = size
 	}
 }
+
+// compositeConcurrencyControl is a ConcurrencyControl that acquires a token from each of its controls.
+type compositeConcurrencyControl struct {
+	controls []ConcurrencyControl
+}
+
+// NewCompositeConcurrencyControl creates a new compositeConcurrencyControl.
+// Acquire acquires a token from every control in order, and the returned token releases them in reverse order.
+// For example, at most 10 batches in flight and 50 batches per second:
+//
+//	NewCompositeConcurrencyControl(NewLimitedConcurrencyControl(10), NewRateLimitedConcurrencyControl(50, 1))
+//
+// If every control is a NonBlockingConcurrencyControl, so is the composite, for example to pass it to
+// NewIdleCapacityScheduler.
+func NewCompositeConcurrencyControl(controls ...ConcurrencyControl) ConcurrencyControl {
+	composite := &compositeConcurrencyControl{
+		controls: controls,
+	}
+
+	nonBlocking := make([]NonBlockingConcurrencyControl, 0, len(controls))
+	for _, control := range controls {
+		nb, ok := control.(NonBlockingConcurrencyControl)
+		if !ok {
+			return composite
+		}
+		nonBlocking = append(nonBlocking, nb)
+	}
+
+	return &nonBlockingCompositeConcurrencyControl{
+		compositeConcurrencyControl: composite,
+		nonBlocking:                 nonBlocking,
+	}
+}
+
+// Acquire acquires a token from every control, releasing the acquired ones if any of them fails.
+func (c *compositeConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	return c.AcquireN(ctx, 1)
+}
+
+// AcquireN acquires a token from every control, weighing n for the weighted ones.
+func (c *compositeConcurrencyControl) AcquireN(ctx context.Context, n int) (ConcurrencyToken, error) {
+	tokens := make([]ConcurrencyToken, 0, len(c.controls))
+	for _, control := range c.controls {
+		token, err := acquireN(ctx, control, n)
+		if err != nil {
+			releaseTokens(tokens, nil)
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return NewFeedbackConcurrencyToken(func(err error) {
+		releaseTokens(tokens, err)
+	}), nil
+}
+
+// nonBlockingCompositeConcurrencyControl is a compositeConcurrencyControl whose controls are all non-blocking.
+type nonBlockingCompositeConcurrencyControl struct {
+	*compositeConcurrencyControl
+	nonBlocking []NonBlockingConcurrencyControl
+}
+
+// TryAcquire acquires a token from every control if all of them have one right now, otherwise it acquires none.
+func (c *nonBlockingCompositeConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	tokens := make([]ConcurrencyToken, 0, len(c.nonBlocking))
+	for _, control := range c.nonBlocking {
+		token, ok := control.TryAcquire()
+		if !ok {
+			releaseTokens(tokens, nil)
+			return nil, false
+		}
+		tokens = append(tokens, token)
+	}
+
+	return NewFeedbackConcurrencyToken(func(err error) {
+		releaseTokens(tokens, err)
+	}), true
+}
+
+// Available returns the fewest tokens available among the controls.
+func (c *nonBlockingCompositeConcurrencyControl) Available() int {
+	available := math.MaxInt
+	for _, control := range c.nonBlocking {
+		available = min(available, control.Available())
+	}
+	return available
+}
+
+// Released returns a channel that is closed the next time capacity frees up in any of the controls.
+// It is nil if none of the controls ever needs to free up.
+func (c *nonBlockingCompositeConcurrencyControl) Released() <-chan struct{} {
+	channels := make([]<-chan struct{}, 0, len(c.nonBlocking))
+	for _, control := range c.nonBlocking {
+		if ch := control.Released(); ch != nil {
+			channels = append(channels, ch)
+		}
+	}
+
+	switch len(channels) {
+	case 0:
+		return nil
+	case 1:
+		return channels[0]
+	}
+
+	released := make(chan struct{})
+	once := &sync.Once{}
+	for _, ch := range channels {
+		go func(ch <-chan struct{}) {
+			select {
+			case <-ch:
+				once.Do(func() {
+					close(released)
+				})
+			case <-released:
+			}
+		}(ch)
+	}
+	return released
+}
+
+// releaseTokens releases the tokens in reverse order.
+func releaseTokens(tokens []ConcurrencyToken, err error) {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		releaseToken(tokens[i], err)
+	}
+}
+
+// acquireN acquires a token weighing n if the control is weighted, otherwise a plain token.
+func acquireN(ctx context.Context, control ConcurrencyControl, n int) (ConcurrencyToken, error) {
+	if weighted, ok := control.(WeightedConcurrencyControl); ok {
+		return weighted.AcquireN(ctx, n)
+	}
+	return control.Acquire(ctx)
+}
+
+// weightedConcurrencyControl is a ConcurrencyControl that limits the total weight of tokens in flight.
+type weightedConcurrencyControl struct {
+	capacity int
+	sem      *semaphore
+}
+
+// NewWeightedConcurrencyControl creates a new weightedConcurrencyControl with the provided capacity.
+// A token weighing more than the capacity is clamped to the capacity, so it waits until nothing else is in flight.
+func NewWeightedConcurrencyControl(capacity int) WeightedConcurrencyControl {
+	return &weightedConcurrencyControl{
+		capacity: capacity,
+		sem:      newSemaphore(capacity),
+	}
+}
+
+// Acquire acquires a concurrency token weighing one.
+func (w *weightedConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	return w.AcquireN(ctx, 1)
+}
+
+// AcquireN acquires a concurrency token weighing n.
+func (w *weightedConcurrencyControl) AcquireN(ctx context.Context, n int) (ConcurrencyToken, error) {
+	if n < 1 {
+		n = 1
+	}
+	if n > w.capacity {
+		n = w.capacity
+	}
+
+	if err := w.sem.acquire(ctx, n); err != nil {
+		return nil, err
+	}
+	return NewConcurrencyToken(func() {
+		w.sem.release(n)
+	}), nil
+}
+
+// TryAcquire acquires a concurrency token weighing one if it is available right now.
+func (w *weightedConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	if !w.sem.tryAcquire(1) {
+		return nil, false
+	}
+	return NewConcurrencyToken(func() {
+		w.sem.release(1)
+	}), true
+}
+
+// Available returns the weight that could be acquired right now.
+func (w *weightedConcurrencyControl) Available() int {
+	return w.sem.available()
+}
+
+// Released returns a channel that is closed the next time a token is released.
+func (w *weightedConcurrencyControl) Released() <-chan struct{} {
+	return w.sem.releasedSignal()
+}
@@ -53,6 +53,140 @@ func (mr *MockConcurrencyControlMockRecorder) Acquire(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockConcurrencyControl)(nil).Acquire), ctx)
 }
 
+// MockWeightedConcurrencyControl is a mock of WeightedConcurrencyControl interface.
+type MockWeightedConcurrencyControl struct {
+	ctrl     *gomock.Controller
+	recorder *MockWeightedConcurrencyControlMockRecorder
+}
+
+// MockWeightedConcurrencyControlMockRecorder is the mock recorder for MockWeightedConcurrencyControl.
+type MockWeightedConcurrencyControlMockRecorder struct {
+	mock *MockWeightedConcurrencyControl
+}
+
+// NewMockWeightedConcurrencyControl creates a new mock instance.
+func NewMockWeightedConcurrencyControl(ctrl *gomock.Controller) *MockWeightedConcurrencyControl {
+	mock := &MockWeightedConcurrencyControl{ctrl: ctrl}
+	mock.recorder = &MockWeightedConcurrencyControlMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWeightedConcurrencyControl) EXPECT() *MockWeightedConcurrencyControlMockRecorder {
+	return m.recorder
+}
+
+// Acquire mocks base method.
+func (m *MockWeightedConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Acquire", ctx)
+	ret0, _ := ret[0].(ConcurrencyToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Acquire indicates an expected call of Acquire.
+func (mr *MockWeightedConcurrencyControlMockRecorder) Acquire(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockWeightedConcurrencyControl)(nil).Acquire), ctx)
+}
+
+// AcquireN mocks base method.
+func (m *MockWeightedConcurrencyControl) AcquireN(ctx context.Context, n int) (ConcurrencyToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireN", ctx, n)
+	ret0, _ := ret[0].(ConcurrencyToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireN indicates an expected call of AcquireN.
+func (mr *MockWeightedConcurrencyControlMockRecorder) AcquireN(ctx, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireN", reflect.TypeOf((*MockWeightedConcurrencyControl)(nil).AcquireN), ctx, n)
+}
+
+// MockNonBlockingConcurrencyControl is a mock of NonBlockingConcurrencyControl interface.
+type MockNonBlockingConcurrencyControl struct {
+	ctrl     *gomock.Controller
+	recorder *MockNonBlockingConcurrencyControlMockRecorder
+}
+
+// MockNonBlockingConcurrencyControlMockRecorder is the mock recorder for MockNonBlockingConcurrencyControl.
+type MockNonBlockingConcurrencyControlMockRecorder struct {
+	mock *MockNonBlockingConcurrencyControl
+}
+
+// NewMockNonBlockingConcurrencyControl creates a new mock instance.
+func NewMockNonBlockingConcurrencyControl(ctrl *gomock.Controller) *MockNonBlockingConcurrencyControl {
+	mock := &MockNonBlockingConcurrencyControl{ctrl: ctrl}
+	mock.recorder = &MockNonBlockingConcurrencyControlMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNonBlockingConcurrencyControl) EXPECT() *MockNonBlockingConcurrencyControlMockRecorder {
+	return m.recorder
+}
+
+// Acquire mocks base method.
+func (m *MockNonBlockingConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Acquire", ctx)
+	ret0, _ := ret[0].(ConcurrencyToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Acquire indicates an expected call of Acquire.
+func (mr *MockNonBlockingConcurrencyControlMockRecorder) Acquire(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockNonBlockingConcurrencyControl)(nil).Acquire), ctx)
+}
+
+// Available mocks base method.
+func (m *MockNonBlockingConcurrencyControl) Available() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Available")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Available indicates an expected call of Available.
+func (mr *MockNonBlockingConcurrencyControlMockRecorder) Available() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Available", reflect.TypeOf((*MockNonBlockingConcurrencyControl)(nil).Available))
+}
+
+// Released mocks base method.
+func (m *MockNonBlockingConcurrencyControl) Released() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Released")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Released indicates an expected call of Released.
+func (mr *MockNonBlockingConcurrencyControlMockRecorder) Released() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Released", reflect.TypeOf((*MockNonBlockingConcurrencyControl)(nil).Released))
+}
+
+// TryAcquire mocks base method.
+func (m *MockNonBlockingConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryAcquire")
+	ret0, _ := ret[0].(ConcurrencyToken)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// TryAcquire indicates an expected call of TryAcquire.
+func (mr *MockNonBlockingConcurrencyControlMockRecorder) TryAcquire() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryAcquire", reflect.TypeOf((*MockNonBlockingConcurrencyControl)(nil).TryAcquire))
+}
+
 // MockConcurrencyToken is a mock of ConcurrencyToken interface.
 type MockConcurrencyToken struct {
 	ctrl     *gomock.Controller
@@ -87,3 +221,87 @@ func (mr *MockConcurrencyTokenMockRecorder) Release() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockConcurrencyToken)(nil).Release))
 }
+
+// MockFeedbackConcurrencyToken is a mock of FeedbackConcurrencyToken interface.
+type MockFeedbackConcurrencyToken struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedbackConcurrencyTokenMockRecorder
+}
+
+// MockFeedbackConcurrencyTokenMockRecorder is the mock recorder for MockFeedbackConcurrencyToken.
+type MockFeedbackConcurrencyTokenMockRecorder struct {
+	mock *MockFeedbackConcurrencyToken
+}
+
+// NewMockFeedbackConcurrencyToken creates a new mock instance.
+func NewMockFeedbackConcurrencyToken(ctrl *gomock.Controller) *MockFeedbackConcurrencyToken {
+	mock := &MockFeedbackConcurrencyToken{ctrl: ctrl}
+	mock.recorder = &MockFeedbackConcurrencyTokenMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeedbackConcurrencyToken) EXPECT() *MockFeedbackConcurrencyTokenMockRecorder {
+	return m.recorder
+}
+
+// Release mocks base method.
+func (m *MockFeedbackConcurrencyToken) Release() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release")
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockFeedbackConcurrencyTokenMockRecorder) Release() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockFeedbackConcurrencyToken)(nil).Release))
+}
+
+// ReleaseWithError mocks base method.
+func (m *MockFeedbackConcurrencyToken) ReleaseWithError(err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReleaseWithError", err)
+}
+
+// ReleaseWithError indicates an expected call of ReleaseWithError.
+func (mr *MockFeedbackConcurrencyTokenMockRecorder) ReleaseWithError(err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseWithError", reflect.TypeOf((*MockFeedbackConcurrencyToken)(nil).ReleaseWithError), err)
+}
+
+// MockconcurrencyLimiter is a mock of concurrencyLimiter interface.
+type MockconcurrencyLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockconcurrencyLimiterMockRecorder
+}
+
+// MockconcurrencyLimiterMockRecorder is the mock recorder for MockconcurrencyLimiter.
+type MockconcurrencyLimiterMockRecorder struct {
+	mock *MockconcurrencyLimiter
+}
+
+// NewMockconcurrencyLimiter creates a new mock instance.
+func NewMockconcurrencyLimiter(ctrl *gomock.Controller) *MockconcurrencyLimiter {
+	mock := &MockconcurrencyLimiter{ctrl: ctrl}
+	mock.recorder = &MockconcurrencyLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockconcurrencyLimiter) EXPECT() *MockconcurrencyLimiterMockRecorder {
+	return m.recorder
+}
+
+// Limit mocks base method.
+func (m *MockconcurrencyLimiter) Limit() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Limit")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Limit indicates an expected call of Limit.
+func (mr *MockconcurrencyLimiterMockRecorder) Limit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Limit", reflect.TypeOf((*MockconcurrencyLimiter)(nil).Limit))
+}
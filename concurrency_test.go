@@ -2,6 +2,7 @@ package batcher
 
 import (
 	"context"
+	"math"
 	"sync"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 var _ = Describe("UnlimitedConcurrencyControl", func() {
@@ -26,6 +28,14 @@ var _ = Describe("UnlimitedConcurrencyControl", func() {
 		Expect(token).ShouldNot(BeNil())
 		Expect(token.Release).ShouldNot(BeNil())
 	})
+
+	It("should always have capacity", func() {
+		cc := unlimitedConcurrencyControl{}
+		token, ok := cc.TryAcquire()
+		Expect(ok).Should(BeTrue())
+		Expect(token).ShouldNot(BeNil())
+		Expect(cc.Available()).Should(Equal(math.MaxInt))
+	})
 })
 
 var _ = Describe("LimitedConcurrencyControl", func() {
@@ -93,13 +103,13 @@ var _ = Describe("LimitedConcurrencyControl", func() {
 			Expect(token.Release).ShouldNot(BeNil())
 		}
 
-		Expect(len(cc.sem)).Should(Equal(limit))
+		Expect(cc.sem.used).Should(Equal(limit))
 		for i, token := range tokens {
 			token.Release()
-			Expect(len(cc.sem)).Should(Equal(limit - i - 1))
+			Expect(cc.sem.used).Should(Equal(limit - i - 1))
 		}
 
-		Expect(len(cc.sem)).Should(Equal(0))
+		Expect(cc.sem.used).Should(Equal(0))
 	})
 
 	It("should queue up requests when concurrency limit is reached", func() {
@@ -141,7 +151,7 @@ var _ = Describe("LimitedConcurrencyControl", func() {
 		wg.Wait()
 
 		Expect(actual).Should(Equal(expected))
-		Expect(len(cc.sem)).Should(Equal(limit - 1))
+		Expect(cc.sem.used).Should(Equal(limit - 1))
 	})
 
 	It("should return error directly if context is cancelled", func() {
@@ -154,6 +164,385 @@ var _ = Describe("LimitedConcurrencyControl", func() {
 	It("should able specify queue length", func() {
 		queueSize := gofakeit.Number(10, 100)
 		cc = NewLimitedConcurrencyControl(limit, WithLimitedConcurrencyControlQueueSize(queueSize)).(*limitedConcurrencyControl)
-		Expect(cap(cc.queue)).Should(Equal(queueSize))
+		Expect(cc.sem.maxQueue).Should(Equal(queueSize))
+	})
+
+	It("should try acquire without blocking", func() {
+		tokens := make([]ConcurrencyToken, limit)
+		for i := 0; i < limit; i++ {
+			Expect(cc.Available()).Should(Equal(limit - i))
+			token, ok := cc.TryAcquire()
+			Expect(ok).Should(BeTrue())
+			tokens[i] = token
+		}
+
+		Expect(cc.Available()).Should(Equal(0))
+		_, ok := cc.TryAcquire()
+		Expect(ok).Should(BeFalse())
+
+		released := cc.Released()
+		Consistently(released).ShouldNot(BeClosed())
+		tokens[0].Release()
+		Eventually(released).Should(BeClosed())
+		Expect(cc.Available()).Should(Equal(1))
+	})
+
+	It("should return ErrQueueFull if queue is full", func() {
+		queueSize := gofakeit.Number(1, 3)
+		cc = NewLimitedConcurrencyControl(limit, WithLimitedConcurrencyControlQueueSize(queueSize)).(*limitedConcurrencyControl)
+		for i := 0; i < limit; i++ {
+			_, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+		}
+
+		wg := &sync.WaitGroup{}
+		wg.Add(queueSize)
+		for i := 0; i < queueSize; i++ {
+			go func() {
+				defer wg.Done()
+				cc.Acquire(ctx)
+			}()
+		}
+		Eventually(func() int {
+			cc.sem.mu.Lock()
+			defer cc.sem.mu.Unlock()
+			return cc.sem.waiters.Len()
+		}).Should(Equal(queueSize))
+
+		_, err := cc.Acquire(ctx)
+		Expect(err).Should(MatchError(ErrQueueFull))
+
+		cancelFunc()
+		wg.Wait()
+	})
+
+	It("should not hand token to cancelled waiter", func() {
+		tokens := make([]ConcurrencyToken, limit)
+		for i := 0; i < limit; i++ {
+			token, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+			tokens[i] = token
+		}
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := cc.Acquire(waitCtx)
+			errs <- err
+		}()
+		Eventually(func() int {
+			cc.sem.mu.Lock()
+			defer cc.sem.mu.Unlock()
+			return cc.sem.waiters.Len()
+		}).Should(Equal(1))
+
+		cancel()
+		Eventually(errs).Should(Receive(MatchError(context.Canceled)))
+
+		tokens[0].Release()
+		token, err := cc.Acquire(ctx)
+		Expect(err).Should(BeNil())
+		Expect(token).ShouldNot(BeNil())
+		Expect(cc.sem.used).Should(Equal(limit))
+	})
+
+	Describe("warm-up", func() {
+		var fakeClock *clocktesting.FakeClock
+
+		BeforeEach(func() {
+			fakeClock = clocktesting.NewFakeClock(time.Now())
+		})
+
+		step := func(d time.Duration) {
+			Eventually(fakeClock.HasWaiters).Should(BeTrue())
+			fakeClock.Step(d)
+		}
+
+		It("should ramp limit linearly", func() {
+			cc = NewLimitedConcurrencyControl(10,
+				WithLimitedConcurrencyControlWarmUp(8*time.Second, 2, WarmUpLinear),
+				WithLimitedConcurrencyControlClock(fakeClock),
+			).(*limitedConcurrencyControl)
+			Expect(cc.Limit()).Should(Equal(2))
+
+			step(time.Second)
+			Eventually(cc.Limit).Should(Equal(3))
+			step(3 * time.Second)
+			Eventually(cc.Limit).Should(Equal(6))
+			step(4 * time.Second)
+			Eventually(cc.Limit).Should(Equal(10))
+		})
+
+		It("should ramp limit exponentially", func() {
+			cc = NewLimitedConcurrencyControl(16,
+				WithLimitedConcurrencyControlWarmUp(4*time.Second, 1, WarmUpExponential),
+				WithLimitedConcurrencyControlClock(fakeClock),
+			).(*limitedConcurrencyControl)
+			Expect(cc.Limit()).Should(Equal(1))
+
+			step(2 * time.Second)
+			Eventually(cc.Limit).Should(BeNumerically("~", 4, 1))
+			Consistently(cc.Limit).Should(BeNumerically("<=", 4))
+			step(2 * time.Second)
+			Eventually(cc.Limit).Should(Equal(16))
+		})
+
+		It("should let waiters in as limit rises", func() {
+			cc = NewLimitedConcurrencyControl(2,
+				WithLimitedConcurrencyControlWarmUp(time.Second, 1, WarmUpLinear),
+				WithLimitedConcurrencyControlClock(fakeClock),
+			).(*limitedConcurrencyControl)
+
+			_, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+
+			acquired := make(chan struct{})
+			go func() {
+				defer close(acquired)
+				cc.Acquire(ctx)
+			}()
+			Consistently(acquired).ShouldNot(BeClosed())
+
+			step(time.Second)
+			Eventually(acquired).Should(BeClosed())
+		})
+
+		It("should restart ramp on warm up", func() {
+			cc = NewLimitedConcurrencyControl(4,
+				WithLimitedConcurrencyControlWarmUp(3*time.Second, 1, WarmUpLinear),
+				WithLimitedConcurrencyControlClock(fakeClock),
+			).(*limitedConcurrencyControl)
+			step(3 * time.Second)
+			Eventually(cc.Limit).Should(Equal(4))
+
+			var warmUp WarmUpConcurrencyControl = cc
+			warmUp.WarmUp()
+			Expect(cc.Limit()).Should(Equal(1))
+			step(time.Second)
+			Eventually(cc.Limit).Should(Equal(2))
+
+			warmUp.WarmUp()
+			Expect(cc.Limit()).Should(Equal(1))
+			step(3 * time.Second)
+			Eventually(cc.Limit).Should(Equal(4))
+		})
+	})
+})
+
+var _ = Describe("CompositeConcurrencyControl", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		first  *limitedConcurrencyControl
+		second *limitedConcurrencyControl
+		cc     ConcurrencyControl
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		first = NewLimitedConcurrencyControl(2).(*limitedConcurrencyControl)
+		second = NewLimitedConcurrencyControl(1).(*limitedConcurrencyControl)
+		cc = NewCompositeConcurrencyControl(first, second)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	It("should acquire and release a token from every control", func() {
+		token, err := cc.Acquire(ctx)
+		Expect(err).Should(BeNil())
+		Expect(first.sem.used).Should(Equal(1))
+		Expect(second.sem.used).Should(Equal(1))
+
+		token.Release()
+		Expect(first.sem.used).Should(Equal(0))
+		Expect(second.sem.used).Should(Equal(0))
+	})
+
+	It("should release acquired tokens if a control fails", func() {
+		_, err := cc.Acquire(ctx)
+		Expect(err).Should(BeNil())
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err = cc.Acquire(timeoutCtx)
+		Expect(err).Should(HaveOccurred())
+		Expect(first.sem.used).Should(Equal(1))
+	})
+
+	Describe("non-blocking", func() {
+		var nb NonBlockingConcurrencyControl
+
+		BeforeEach(func() {
+			goods := Goroutines()
+			DeferCleanup(func() {
+				Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+			})
+		})
+
+		BeforeEach(func() {
+			var ok bool
+			nb, ok = cc.(NonBlockingConcurrencyControl)
+			Expect(ok).Should(BeTrue())
+		})
+
+		It("should try acquire from every control or none", func() {
+			Expect(nb.Available()).Should(Equal(1))
+			token, ok := nb.TryAcquire()
+			Expect(ok).Should(BeTrue())
+			Expect(first.sem.used).Should(Equal(1))
+			Expect(second.sem.used).Should(Equal(1))
+
+			Expect(nb.Available()).Should(Equal(0))
+			_, ok = nb.TryAcquire()
+			Expect(ok).Should(BeFalse())
+			Expect(first.sem.used).Should(Equal(1))
+
+			token.Release()
+			Expect(first.sem.used).Should(Equal(0))
+			Expect(second.sem.used).Should(Equal(0))
+		})
+
+		It("should signal when any control releases", func() {
+			token, ok := nb.TryAcquire()
+			Expect(ok).Should(BeTrue())
+
+			released := nb.Released()
+			Consistently(released).ShouldNot(BeClosed())
+			token.Release()
+			Eventually(released).Should(BeClosed())
+		})
+
+		It("should not be non-blocking if a control is not", func() {
+			_, ok := NewCompositeConcurrencyControl(first, NewKeyedConcurrencyControl(1)).(NonBlockingConcurrencyControl)
+			Expect(ok).Should(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("WeightedConcurrencyControl", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		cc       *weightedConcurrencyControl
+		capacity int
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		capacity = gofakeit.Number(3, 10)
+		cc = NewWeightedConcurrencyControl(capacity).(*weightedConcurrencyControl)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	acquireAsync := func(n int) chan ConcurrencyToken {
+		acquired := make(chan ConcurrencyToken, 1)
+		go func() {
+			token, err := cc.AcquireN(ctx, n)
+			if err == nil {
+				acquired <- token
+			}
+		}()
+		return acquired
+	}
+
+	It("should acquire and release token by weight", func() {
+		token, err := cc.AcquireN(ctx, capacity-1)
+		Expect(err).Should(BeNil())
+		Expect(cc.sem.used).Should(Equal(capacity - 1))
+
+		token.Release()
+		Expect(cc.sem.used).Should(Equal(0))
+	})
+
+	It("should clamp weight to capacity", func() {
+		token, err := cc.AcquireN(ctx, capacity*2)
+		Expect(err).Should(BeNil())
+		Expect(cc.sem.used).Should(Equal(capacity))
+		token.Release()
+	})
+
+	It("should serve waiters in order", func() {
+		token, err := cc.AcquireN(ctx, capacity-1)
+		Expect(err).Should(BeNil())
+
+		large := acquireAsync(2)
+		Eventually(func() int {
+			cc.sem.mu.Lock()
+			defer cc.sem.mu.Unlock()
+			return cc.sem.waiters.Len()
+		}).Should(Equal(1))
+
+		small := acquireAsync(1)
+		Consistently(small).ShouldNot(Receive())
+
+		token.Release()
+		var largeToken ConcurrencyToken
+		Eventually(large).Should(Receive(&largeToken))
+		Eventually(small).Should(Receive())
+		largeToken.Release()
+	})
+
+	It("should remove cancelled waiter from queue", func() {
+		token, err := cc.AcquireN(ctx, capacity)
+		Expect(err).Should(BeNil())
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := cc.AcquireN(waitCtx, 1)
+			errs <- err
+		}()
+		Eventually(func() int {
+			cc.sem.mu.Lock()
+			defer cc.sem.mu.Unlock()
+			return cc.sem.waiters.Len()
+		}).Should(Equal(1))
+
+		cancel()
+		Eventually(errs).Should(Receive(MatchError(context.Canceled)))
+		Expect(cc.sem.waiters.Len()).Should(Equal(0))
+
+		token.Release()
+		Expect(cc.sem.used).Should(Equal(0))
+	})
+
+	It("should signal when cancelled waiter frees capacity", func() {
+		token, err := cc.AcquireN(ctx, capacity-1)
+		Expect(err).Should(BeNil())
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := cc.AcquireN(waitCtx, 2)
+			errs <- err
+		}()
+		Eventually(func() int {
+			cc.sem.mu.Lock()
+			defer cc.sem.mu.Unlock()
+			return cc.sem.waiters.Len()
+		}).Should(Equal(1))
+		Expect(cc.Available()).Should(Equal(0))
+
+		released := cc.Released()
+		cancel()
+		Eventually(errs).Should(Receive(MatchError(context.Canceled)))
+		Eventually(released).Should(BeClosed())
+		Expect(cc.Available()).Should(Equal(1))
+		token.Release()
 	})
 })
@@ -0,0 +1,114 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// DeadlineScheduler is a Scheduler that dispatches batches after a time window, or earlier if a request of the batch
+// has a deadline, so that the batch is performed before the earliest deadline. It subtracts an estimate of how long
+// Perform takes from the deadline, which it learns from the performed batches.
+type DeadlineScheduler struct {
+	mu         sync.Mutex
+	clock      clock.Clock
+	timeWindow time.Duration
+	smoothing  float64
+	estimate   time.Duration
+}
+
+// NewDeadlineScheduler creates a new DeadlineScheduler with the provided time window.
+// The Perform estimate starts at zero unless set with WithDeadlineSchedulerPerformEstimate.
+func NewDeadlineScheduler(timeWindow time.Duration, option ...deadlineSchedulerOption) Scheduler {
+	s := &DeadlineScheduler{
+		clock:      clock.RealClock{},
+		timeWindow: timeWindow,
+		smoothing:  0.2,
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	return s
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+// The dispatch time is computed again every time a request is appended, as it may have an earlier deadline.
+func (d *DeadlineScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	windowEnd := d.clock.Now().Add(d.timeWindow)
+	for {
+		appended := batch.Appended()
+		dispatchAt := windowEnd
+		if deadline, ok := batch.Deadline(); ok {
+			if at := deadline.Add(-d.PerformEstimate()); at.Before(dispatchAt) {
+				dispatchAt = at
+			}
+		}
+
+		delay := dispatchAt.Sub(d.clock.Now())
+		if delay <= 0 {
+			callback.Call()
+			return
+		}
+
+		timer := d.clock.NewTimer(delay)
+		select {
+		case <-appended:
+			timer.Stop()
+			continue
+		case <-ctx.Done():
+		case <-batch.Dispatch():
+			callback.Call()
+		case <-batch.Full():
+			callback.Call()
+		case <-timer.C():
+			callback.Call()
+		}
+		timer.Stop()
+		return
+	}
+}
+
+// Observe updates the Perform estimate with a performed batch.
+func (d *DeadlineScheduler) Observe(observation BatchObservation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.estimate += time.Duration(float64(observation.Perform-d.estimate) * d.smoothing)
+}
+
+// PerformEstimate returns the current estimate of how long Perform takes.
+func (d *DeadlineScheduler) PerformEstimate() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.estimate
+}
+
+// deadlineSchedulerOption is a function that configures a DeadlineScheduler.
+type deadlineSchedulerOption func(*DeadlineScheduler)
+
+// WithDeadlineSchedulerPerformEstimate returns an option that sets the initial Perform estimate of a DeadlineScheduler.
+func WithDeadlineSchedulerPerformEstimate(estimate time.Duration) deadlineSchedulerOption {
+	return func(d *DeadlineScheduler) {
+		d.estimate = estimate
+	}
+}
+
+// WithDeadlineSchedulerSmoothing returns an option that sets the weight of every performed batch
+// in the Perform estimate of a DeadlineScheduler, between zero and one.
+func WithDeadlineSchedulerSmoothing(smoothing float64) deadlineSchedulerOption {
+	return func(d *DeadlineScheduler) {
+		d.smoothing = smoothing
+	}
+}
+
+// WithDeadlineSchedulerClock returns an option that sets the clock for a DeadlineScheduler.
+func WithDeadlineSchedulerClock(clock clock.Clock) deadlineSchedulerOption {
+	return func(d *DeadlineScheduler) {
+		d.clock = clock
+	}
+}
@@ -0,0 +1,162 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("DeadlineScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock    *clocktesting.FakeClock
+		mockBatch    *MockBatch
+		mockCallback *MockSchedulerCallback
+
+		mu       sync.Mutex
+		deadline time.Time
+		appended chan chan struct{}
+
+		dispatchTrigger chan struct{}
+		fullTrigger     chan struct{}
+		called          chan struct{}
+		returned        chan struct{}
+
+		scheduler *DeadlineScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		scheduler = NewDeadlineScheduler(time.Second,
+			WithDeadlineSchedulerClock(fakeClock),
+			WithDeadlineSchedulerPerformEstimate(100*time.Millisecond),
+		).(*DeadlineScheduler)
+
+		deadline = time.Time{}
+		appended = make(chan chan struct{}, 1)
+		dispatchTrigger = make(chan struct{})
+		fullTrigger = make(chan struct{})
+		called = make(chan struct{})
+		returned = make(chan struct{})
+
+		mockBatch = NewMockBatch(ctrl)
+		mockBatch.EXPECT().Appended().DoAndReturn(func() <-chan struct{} {
+			ch := make(chan struct{})
+			appended <- ch
+			return ch
+		}).AnyTimes()
+		mockBatch.EXPECT().Deadline().DoAndReturn(func() (time.Time, bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			return deadline, !deadline.IsZero()
+		}).AnyTimes()
+		mockBatch.EXPECT().Dispatch().Return(dispatchTrigger).AnyTimes()
+		mockBatch.EXPECT().Full().Return(fullTrigger).AnyTimes()
+
+		// Schedule may call back right away, so the call is expected before it starts.
+		mockCallback = NewMockSchedulerCallback(ctrl)
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			defer close(returned)
+			scheduler.Schedule(ctx, mockBatch, mockCallback)
+		}()
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+		Eventually(returned).Should(BeClosed())
+	})
+
+	expectCallAfter := func(d time.Duration) {
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(d - time.Millisecond)
+		Consistently(called).ShouldNot(BeClosed())
+		fakeClock.Step(time.Millisecond)
+		Eventually(called).Should(BeClosed())
+	}
+
+	setDeadline := func(d time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		deadline = d
+	}
+
+	It("should call callback after time window without deadline", func() {
+		expectCallAfter(time.Second)
+	})
+
+	Context("with deadline before time window", func() {
+		BeforeEach(func() {
+			setDeadline(fakeClock.Now().Add(500 * time.Millisecond))
+		})
+
+		It("should call callback before deadline", func() {
+			expectCallAfter(400 * time.Millisecond)
+		})
+	})
+
+	Context("with deadline after time window", func() {
+		BeforeEach(func() {
+			setDeadline(fakeClock.Now().Add(time.Hour))
+		})
+
+		It("should call callback after time window", func() {
+			expectCallAfter(time.Second)
+		})
+	})
+
+	Context("with deadline already passed", func() {
+		BeforeEach(func() {
+			setDeadline(fakeClock.Now().Add(50 * time.Millisecond))
+		})
+
+		It("should call callback instantly", func() {
+			Eventually(called).Should(BeClosed())
+		})
+	})
+
+	It("should move dispatch earlier when appended request has earlier deadline", func() {
+		var ch chan struct{}
+		Eventually(appended).Should(Receive(&ch))
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+
+		setDeadline(fakeClock.Now().Add(300 * time.Millisecond))
+		close(ch)
+		Eventually(appended).Should(Receive())
+		expectCallAfter(200 * time.Millisecond)
+	})
+
+	It("should learn perform estimate", func() {
+		for i := 0; i < 50; i++ {
+			scheduler.Observe(BatchObservation{Size: 1, Perform: 300 * time.Millisecond})
+		}
+		Expect(scheduler.PerformEstimate()).Should(BeNumerically("~", 300*time.Millisecond, time.Millisecond))
+		close(dispatchTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should call callback if full triggered", func() {
+		close(fullTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+})
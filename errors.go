@@ -0,0 +1,26 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrActionTimeout is returned to the thunks of a batch whose Perform did not return within the action timeout.
+// It wraps context.DeadlineExceeded.
+var ErrActionTimeout = fmt.Errorf("batcher: action timeout: %w", context.DeadlineExceeded)
+
+// ErrCircuitOpen is returned to the thunks of a batch that is rejected because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("batcher: circuit breaker is open")
+
+// ErrQueueFull is returned by Acquire when the waiter queue of a concurrency control is full.
+var ErrQueueFull = errors.New("batcher: concurrency control queue is full")
+
+// ErrBudgetOverReserved is returned by ConcurrencyBudget.Control when the reservations would exceed the budget size.
+var ErrBudgetOverReserved = errors.New("batcher: concurrency budget reservations exceed its size")
+
+// ErrLeaseUnavailable is returned by LeaseStore.Acquire when every lease is held.
+var ErrLeaseUnavailable = errors.New("batcher: no lease available")
+
+// ErrLeaseLost is returned by LeaseStore.Renew and LeaseStore.Release when the lease expired or was taken over.
+var ErrLeaseLost = errors.New("batcher: lease lost")
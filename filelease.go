@@ -0,0 +1,202 @@
+package batcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// fileLeaseStore is a LeaseStore that keeps each lease in a lock file of a directory,
+// so processes sharing the directory share the leases.
+type fileLeaseStore struct {
+	dir   string
+	clock clock.PassiveClock
+}
+
+// NewFileLeaseStore creates a new LeaseStore that keeps the leases as lock files in dir, which is created if needed.
+// A lock file holds the id of its lease and its modification time is the expiry of the lease, so the directory must be
+// on a file system with sub-second modification times. Expired lock files are reclaimed by renaming them away.
+func NewFileLeaseStore(dir string, option ...fileLeaseStoreOption) LeaseStore {
+	s := &fileLeaseStore{
+		dir:   dir,
+		clock: clock.RealClock{},
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	return s
+}
+
+// Acquire implements the LeaseStore interface.
+func (f *fileLeaseStore) Acquire(ctx context.Context, limit int, ttl time.Duration) (string, error) {
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	id, err := leaseID()
+	if err != nil {
+		return "", err
+	}
+
+	for slot := 0; slot < limit; slot++ {
+		claimed, err := f.claim(slot, id, ttl)
+		if err != nil {
+			return "", err
+		}
+		if claimed {
+			return strconv.Itoa(slot) + "/" + id, nil
+		}
+	}
+	return "", ErrLeaseUnavailable
+}
+
+// Renew implements the LeaseStore interface.
+func (f *fileLeaseStore) Renew(ctx context.Context, lease string, ttl time.Duration) error {
+	path, err := f.held(lease)
+	if err != nil {
+		return err
+	}
+
+	now := f.clock.Now()
+	return os.Chtimes(path, now, now.Add(ttl))
+}
+
+// Release implements the LeaseStore interface.
+func (f *fileLeaseStore) Release(ctx context.Context, lease string) error {
+	path, err := f.held(lease)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// claim claims the slot for the lease id, reclaiming the lock file of the slot if it expired.
+func (f *fileLeaseStore) claim(slot int, id string, ttl time.Duration) (bool, error) {
+	path := f.path(slot)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && f.clock.Now().Before(info.ModTime()):
+		return false, nil
+	case err == nil:
+		if err := f.reclaim(path); err != nil {
+			return false, err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return false, err
+	}
+
+	// Write the lock file aside and link it in place, so it is never seen without its id and expiry.
+	tmp := path + "." + id + ".tmp"
+	if err := os.WriteFile(tmp, []byte(id), 0o644); err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp)
+
+	now := f.clock.Now()
+	if err := os.Chtimes(tmp, now, now.Add(ttl)); err != nil {
+		return false, err
+	}
+	if err := os.Link(tmp, path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// reclaim removes the expired lock file at path. Only one process can rename a lock file away,
+// and if another process claimed the slot in the meantime its lock file is put back.
+func (f *fileLeaseStore) reclaim(path string) error {
+	id, err := leaseID()
+	if err != nil {
+		return err
+	}
+
+	stale := path + "." + id + ".stale"
+	if err := os.Rename(path, stale); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(stale)
+
+	info, err := os.Stat(stale)
+	if err != nil {
+		return err
+	}
+	if f.clock.Now().Before(info.ModTime()) {
+		// If the slot is claimed again before it is put back, the renewal of this lease reports it lost.
+		_ = os.Link(stale, path)
+	}
+	return nil
+}
+
+// held returns the path of the lock file of the lease, or ErrLeaseLost if the lease expired or was taken over.
+func (f *fileLeaseStore) held(lease string) (string, error) {
+	slot, id, ok := strings.Cut(lease, "/")
+	n, err := strconv.Atoi(slot)
+	if !ok || err != nil {
+		return "", fmt.Errorf("batcher: invalid lease %q", lease)
+	}
+
+	path := f.path(n)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrLeaseLost
+	}
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(content, []byte(id)) {
+		return "", ErrLeaseLost
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !f.clock.Now().Before(info.ModTime()) {
+		return "", ErrLeaseLost
+	}
+	return path, nil
+}
+
+// path returns the path of the lock file of the slot.
+func (f *fileLeaseStore) path(slot int) string {
+	return filepath.Join(f.dir, "slot-"+strconv.Itoa(slot)+".lease")
+}
+
+// leaseID returns a random lease id.
+func leaseID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// fileLeaseStoreOption is a function that configures a fileLeaseStore.
+type fileLeaseStoreOption func(*fileLeaseStore)
+
+// WithFileLeaseStoreClock returns an option that sets the clock for a fileLeaseStore.
+func WithFileLeaseStoreClock(clock clock.PassiveClock) fileLeaseStoreOption {
+	return func(f *fileLeaseStore) {
+		f.clock = clock
+	}
+}
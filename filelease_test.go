@@ -0,0 +1,97 @@
+package batcher
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("FileLeaseStore", func() {
+	var (
+		ctx context.Context
+
+		fakeClock *clocktesting.FakePassiveClock
+		dir       string
+		store     LeaseStore
+		ttl       time.Duration
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeClock = clocktesting.NewFakePassiveClock(time.Now())
+		dir = GinkgoT().TempDir()
+		store = NewFileLeaseStore(dir, WithFileLeaseStoreClock(fakeClock))
+		ttl = 3 * time.Second
+	})
+
+	files := func() []string {
+		entries, err := os.ReadDir(dir)
+		Expect(err).Should(BeNil())
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		return names
+	}
+
+	It("should limit leases", func() {
+		first, err := store.Acquire(ctx, 2, ttl)
+		Expect(err).Should(BeNil())
+		_, err = store.Acquire(ctx, 2, ttl)
+		Expect(err).Should(BeNil())
+		_, err = store.Acquire(ctx, 2, ttl)
+		Expect(err).Should(MatchError(ErrLeaseUnavailable))
+
+		Expect(store.Release(ctx, first)).Should(Succeed())
+		_, err = store.Acquire(ctx, 2, ttl)
+		Expect(err).Should(BeNil())
+		Expect(files()).Should(ConsistOf("slot-0.lease", "slot-1.lease"))
+	})
+
+	It("should share leases between stores of the same directory", func() {
+		other := NewFileLeaseStore(dir, WithFileLeaseStoreClock(fakeClock))
+		_, err := store.Acquire(ctx, 1, ttl)
+		Expect(err).Should(BeNil())
+		_, err = other.Acquire(ctx, 1, ttl)
+		Expect(err).Should(MatchError(ErrLeaseUnavailable))
+	})
+
+	It("should renew leases", func() {
+		lease, err := store.Acquire(ctx, 1, ttl)
+		Expect(err).Should(BeNil())
+
+		fakeClock.SetTime(fakeClock.Now().Add(ttl / 2))
+		Expect(store.Renew(ctx, lease, ttl)).Should(Succeed())
+		fakeClock.SetTime(fakeClock.Now().Add(ttl / 2).Add(time.Millisecond))
+
+		_, err = store.Acquire(ctx, 1, ttl)
+		Expect(err).Should(MatchError(ErrLeaseUnavailable))
+		Expect(store.Renew(ctx, lease, ttl)).Should(Succeed())
+	})
+
+	It("should reclaim expired leases", func() {
+		expired, err := store.Acquire(ctx, 1, ttl)
+		Expect(err).Should(BeNil())
+
+		fakeClock.SetTime(fakeClock.Now().Add(ttl))
+		Expect(store.Renew(ctx, expired, ttl)).Should(MatchError(ErrLeaseLost))
+
+		lease, err := store.Acquire(ctx, 1, ttl)
+		Expect(err).Should(BeNil())
+		Expect(lease).ShouldNot(Equal(expired))
+		Expect(store.Renew(ctx, expired, ttl)).Should(MatchError(ErrLeaseLost))
+		Expect(store.Release(ctx, expired)).Should(MatchError(ErrLeaseLost))
+		Expect(files()).Should(ConsistOf("slot-0.lease"))
+
+		Expect(store.Release(ctx, lease)).Should(Succeed())
+		Expect(files()).Should(BeEmpty())
+	})
+
+	It("should reject invalid leases", func() {
+		Expect(store.Renew(ctx, "invalid", ttl)).ShouldNot(Succeed())
+	})
+})
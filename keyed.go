@@ -0,0 +1,100 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+)
+
+// keyedConcurrencyControl is a ConcurrencyControl that limits concurrency per partition key,
+// and optionally across all keys.
+type keyedConcurrencyControl struct {
+	mu     sync.Mutex
+	perKey int
+	keys   map[string]*keyedSemaphore
+	global *semaphore
+}
+
+// keyedSemaphore is the semaphore of a partition key and the number of callers holding or waiting on it.
+type keyedSemaphore struct {
+	sem  *semaphore
+	refs int
+}
+
+// NewKeyedConcurrencyControl creates a new keyedConcurrencyControl that lets perKey batches of each partition key
+// be in flight. The partition key is read from the context with PartitionKeyFromContext, which the batcher sets
+// from the key of the batch. The semaphore of a key is evicted as soon as no caller holds or waits on it.
+func NewKeyedConcurrencyControl(perKey int, option ...keyedConcurrencyControlOption) ConcurrencyControl {
+	cc := &keyedConcurrencyControl{
+		perKey: perKey,
+		keys:   map[string]*keyedSemaphore{},
+	}
+
+	for _, opt := range option {
+		opt(cc)
+	}
+
+	return cc
+}
+
+// Acquire acquires a concurrency token for the partition key of ctx, then from the global limit if set.
+func (k *keyedConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	key, _ := PartitionKeyFromContext(ctx)
+	keyed := k.ref(key)
+
+	if err := keyed.sem.acquire(ctx, 1); err != nil {
+		k.unref(key)
+		return nil, err
+	}
+
+	if k.global != nil {
+		if err := k.global.acquire(ctx, 1); err != nil {
+			keyed.sem.release(1)
+			k.unref(key)
+			return nil, err
+		}
+	}
+
+	return NewConcurrencyToken(func() {
+		if k.global != nil {
+			k.global.release(1)
+		}
+		keyed.sem.release(1)
+		k.unref(key)
+	}), nil
+}
+
+// ref returns the semaphore of the key, creating it if needed.
+func (k *keyedConcurrencyControl) ref(key string) *keyedSemaphore {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keyed, ok := k.keys[key]
+	if !ok {
+		keyed = &keyedSemaphore{sem: newSemaphore(k.perKey)}
+		k.keys[key] = keyed
+	}
+	keyed.refs++
+	return keyed
+}
+
+// unref evicts the semaphore of the key once nobody holds or waits on it.
+func (k *keyedConcurrencyControl) unref(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keyed := k.keys[key]
+	keyed.refs--
+	if keyed.refs == 0 {
+		delete(k.keys, key)
+	}
+}
+
+// keyedConcurrencyControlOption is a function that configures a keyedConcurrencyControl.
+type keyedConcurrencyControlOption func(*keyedConcurrencyControl)
+
+// WithKeyedConcurrencyControlGlobalLimit returns an option that also limits the batches in flight across all keys.
+func WithKeyedConcurrencyControlGlobalLimit(limit int) keyedConcurrencyControlOption {
+	return func(k *keyedConcurrencyControl) {
+		k.global = newSemaphore(limit)
+	}
+}
@@ -0,0 +1,79 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+)
+
+var _ = Describe("KeyedConcurrencyControl", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		perKey int
+		cc     *keyedConcurrencyControl
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		perKey = gofakeit.Number(1, 3)
+		cc = NewKeyedConcurrencyControl(perKey, WithKeyedConcurrencyControlGlobalLimit(perKey*2)).(*keyedConcurrencyControl)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	acquireN := func(key string, n int) []ConcurrencyToken {
+		tokens := make([]ConcurrencyToken, n)
+		for i := range tokens {
+			token, err := cc.Acquire(ContextWithPartitionKey(ctx, key))
+			Expect(err).Should(BeNil())
+			tokens[i] = token
+		}
+		return tokens
+	}
+
+	blocked := func(key string) {
+		timeoutCtx, cancel := context.WithTimeout(ContextWithPartitionKey(ctx, key), 10*time.Millisecond)
+		defer cancel()
+		_, err := cc.Acquire(timeoutCtx)
+		Expect(err).Should(MatchError(context.DeadlineExceeded))
+	}
+
+	It("should limit concurrency per key", func() {
+		acquireN("foo", perKey)
+		blocked("foo")
+		acquireN("bar", perKey)
+	})
+
+	It("should limit concurrency globally", func() {
+		acquireN("foo", perKey)
+		acquireN("bar", perKey)
+		blocked("baz")
+	})
+
+	It("should evict idle keys", func() {
+		tokens := acquireN("foo", perKey)
+		blocked("foo")
+		Expect(cc.keys).Should(HaveLen(1))
+
+		for _, token := range tokens {
+			token.Release()
+		}
+		Expect(cc.keys).Should(BeEmpty())
+		Expect(cc.global.used).Should(Equal(0))
+	})
+})
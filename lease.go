@@ -0,0 +1,249 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// LeaseStore stores the leases of a lease-based ConcurrencyControl, so that replicas sharing a store
+// share the limit. A lease that is not renewed within its ttl expires, so the leases of a crashed replica
+// are given back on their own.
+type LeaseStore interface {
+	// Acquire takes one of limit leases for ttl and returns its id.
+	// It fails with ErrLeaseUnavailable if limit leases are held.
+	Acquire(ctx context.Context, limit int, ttl time.Duration) (string, error)
+	// Renew extends the lease for ttl from now. It fails with ErrLeaseLost if the lease expired or was taken over.
+	Renew(ctx context.Context, lease string, ttl time.Duration) error
+	// Release gives the lease back. It fails with ErrLeaseLost if the lease expired or was taken over.
+	Release(ctx context.Context, lease string) error
+}
+
+// leaseConcurrencyControl is a ConcurrencyControl that holds a lease of a LeaseStore for every token.
+type leaseConcurrencyControl struct {
+	store        LeaseStore
+	limit        int
+	ttl          time.Duration
+	clock        clock.Clock
+	pollInterval time.Duration
+	onLost       func(lease string, err error)
+}
+
+// NewLeaseConcurrencyControl creates a new leaseConcurrencyControl that lets limit batches be in flight
+// across every replica sharing the store. Each token holds a lease for ttl, renewed every third of ttl
+// while the token is held. Acquire polls the store every tenth of ttl while every lease is held,
+// unless set with WithLeaseConcurrencyControlPollInterval.
+func NewLeaseConcurrencyControl(store LeaseStore, limit int, ttl time.Duration, option ...leaseConcurrencyControlOption) ConcurrencyControl {
+	cc := &leaseConcurrencyControl{
+		store:        store,
+		limit:        limit,
+		ttl:          ttl,
+		clock:        clock.RealClock{},
+		pollInterval: ttl / 10,
+		onLost:       func(string, error) {},
+	}
+
+	for _, opt := range option {
+		opt(cc)
+	}
+
+	return cc
+}
+
+// Acquire blocks until a lease is acquired or ctx is done.
+func (l *leaseConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		lease, err := l.store.Acquire(ctx, l.limit, l.ttl)
+		if err == nil {
+			return l.token(lease), nil
+		}
+		if !errors.Is(err, ErrLeaseUnavailable) {
+			return nil, err
+		}
+
+		timer := l.clock.NewTimer(l.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// Limit returns the concurrency limit.
+func (l *leaseConcurrencyControl) Limit() int {
+	return l.limit
+}
+
+// token creates a token that renews the lease until it is released.
+func (l *leaseConcurrencyControl) token(lease string) ConcurrencyToken {
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	lost := false
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lost = l.renew(ctx, lease)
+	}()
+
+	return NewConcurrencyToken(func() {
+		cancel()
+		wg.Wait()
+		if !lost {
+			// An expired lease is given back by the store, so the error can be ignored.
+			_ = l.store.Release(context.Background(), lease)
+		}
+	})
+}
+
+// renew renews the lease every third of the ttl until ctx is done, and returns whether the lease was lost.
+// A failed renewal is retried until the lease would have expired, unless the store reports it lost.
+func (l *leaseConcurrencyControl) renew(ctx context.Context, lease string) bool {
+	renewedAt := l.clock.Now()
+	for {
+		timer := l.clock.NewTimer(l.ttl / 3)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C():
+		}
+
+		err := l.store.Renew(ctx, lease, l.ttl)
+		switch {
+		case err == nil:
+			renewedAt = l.clock.Now()
+		case ctx.Err() != nil:
+			return false
+		case errors.Is(err, ErrLeaseLost) || l.clock.Since(renewedAt) >= l.ttl:
+			l.onLost(lease, err)
+			return true
+		}
+	}
+}
+
+// leaseConcurrencyControlOption is a function that configures a leaseConcurrencyControl.
+type leaseConcurrencyControlOption func(*leaseConcurrencyControl)
+
+// WithLeaseConcurrencyControlClock returns an option that sets the clock for a leaseConcurrencyControl.
+func WithLeaseConcurrencyControlClock(clock clock.Clock) leaseConcurrencyControlOption {
+	return func(l *leaseConcurrencyControl) {
+		l.clock = clock
+	}
+}
+
+// WithLeaseConcurrencyControlPollInterval returns an option that sets how often Acquire polls the store
+// while every lease is held.
+func WithLeaseConcurrencyControlPollInterval(interval time.Duration) leaseConcurrencyControlOption {
+	return func(l *leaseConcurrencyControl) {
+		l.pollInterval = interval
+	}
+}
+
+// WithLeaseConcurrencyControlLostCallback returns an option that sets a function called when the lease of a token
+// could not be renewed. The token stops renewing and does not give the lease back when released,
+// as the lease may already be held by another replica.
+func WithLeaseConcurrencyControlLostCallback(callback func(lease string, err error)) leaseConcurrencyControlOption {
+	return func(l *leaseConcurrencyControl) {
+		l.onLost = callback
+	}
+}
+
+// memoryLeaseStore is a LeaseStore that keeps the leases in memory, for tests and single process use.
+type memoryLeaseStore struct {
+	mu     sync.Mutex
+	clock  clock.PassiveClock
+	leases map[string]time.Time
+	next   int
+}
+
+// NewMemoryLeaseStore creates a new LeaseStore that keeps the leases in memory.
+func NewMemoryLeaseStore(option ...memoryLeaseStoreOption) LeaseStore {
+	s := &memoryLeaseStore{
+		clock:  clock.RealClock{},
+		leases: map[string]time.Time{},
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	return s
+}
+
+// Acquire implements the LeaseStore interface.
+func (m *memoryLeaseStore) Acquire(ctx context.Context, limit int, ttl time.Duration) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	for lease, expiry := range m.leases {
+		if !now.Before(expiry) {
+			delete(m.leases, lease)
+		}
+	}
+	if len(m.leases) >= limit {
+		return "", ErrLeaseUnavailable
+	}
+
+	m.next++
+	lease := strconv.Itoa(m.next)
+	m.leases[lease] = now.Add(ttl)
+	return lease, nil
+}
+
+// Renew implements the LeaseStore interface.
+func (m *memoryLeaseStore) Renew(ctx context.Context, lease string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.held(lease) {
+		return ErrLeaseLost
+	}
+	m.leases[lease] = m.clock.Now().Add(ttl)
+	return nil
+}
+
+// Release implements the LeaseStore interface.
+func (m *memoryLeaseStore) Release(ctx context.Context, lease string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.held(lease) {
+		return ErrLeaseLost
+	}
+	delete(m.leases, lease)
+	return nil
+}
+
+// held returns whether the lease exists and has not expired, it must be called with the lock held.
+func (m *memoryLeaseStore) held(lease string) bool {
+	expiry, ok := m.leases[lease]
+	if ok && !m.clock.Now().Before(expiry) {
+		delete(m.leases, lease)
+		return false
+	}
+	return ok
+}
+
+// memoryLeaseStoreOption is a function that configures a memoryLeaseStore.
+type memoryLeaseStoreOption func(*memoryLeaseStore)
+
+// WithMemoryLeaseStoreClock returns an option that sets the clock for a memoryLeaseStore.
+func WithMemoryLeaseStoreClock(clock clock.PassiveClock) memoryLeaseStoreOption {
+	return func(m *memoryLeaseStore) {
+		m.clock = clock
+	}
+}
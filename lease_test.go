@@ -0,0 +1,182 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// flakyLeaseStore is a LeaseStore whose renewals fail with renewErr when it is set.
+type flakyLeaseStore struct {
+	LeaseStore
+	renewErr chan error
+}
+
+func (f *flakyLeaseStore) Renew(ctx context.Context, lease string, ttl time.Duration) error {
+	select {
+	case err := <-f.renewErr:
+		return err
+	default:
+		return f.LeaseStore.Renew(ctx, lease, ttl)
+	}
+}
+
+var _ = Describe("LeaseConcurrencyControl", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakeClock
+		store     *memoryLeaseStore
+		ttl       time.Duration
+		limit     int
+		lost      chan error
+		newCC     func(store LeaseStore) ConcurrencyControl
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		store = NewMemoryLeaseStore(WithMemoryLeaseStoreClock(fakeClock)).(*memoryLeaseStore)
+		ttl = 3 * time.Second
+		limit = 2
+		lost = make(chan error, 1)
+		newCC = func(store LeaseStore) ConcurrencyControl {
+			return NewLeaseConcurrencyControl(store, limit, ttl,
+				WithLeaseConcurrencyControlClock(fakeClock),
+				WithLeaseConcurrencyControlPollInterval(100*time.Millisecond),
+				WithLeaseConcurrencyControlLostCallback(func(lease string, err error) {
+					lost <- err
+				}),
+			)
+		}
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	acquireAsync := func(cc ConcurrencyControl) chan ConcurrencyToken {
+		tokens := make(chan ConcurrencyToken, 1)
+		go func() {
+			token, err := cc.Acquire(ctx)
+			if err == nil {
+				tokens <- token
+			}
+		}()
+		return tokens
+	}
+
+	expiry := func() time.Time {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for _, expiry := range store.leases {
+			return expiry
+		}
+		return time.Time{}
+	}
+
+	step := func(d time.Duration) {
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(d)
+	}
+
+	It("should limit leases across controls", func() {
+		first, second := newCC(store), newCC(store)
+		firstToken, err := first.Acquire(ctx)
+		Expect(err).Should(BeNil())
+		secondToken, err := second.Acquire(ctx)
+		Expect(err).Should(BeNil())
+
+		blocked := acquireAsync(second)
+		Consistently(blocked).ShouldNot(Receive())
+
+		firstToken.Release()
+		fakeClock.Step(100 * time.Millisecond)
+		var token ConcurrencyToken
+		Eventually(blocked).Should(Receive(&token))
+
+		token.Release()
+		secondToken.Release()
+		Expect(store.leases).Should(BeEmpty())
+	})
+
+	It("should renew leases while held", func() {
+		token, err := newCC(store).Acquire(ctx)
+		Expect(err).Should(BeNil())
+
+		for i := 0; i < 6; i++ {
+			step(ttl / 3)
+			Eventually(expiry).Should(Equal(fakeClock.Now().Add(ttl)))
+		}
+		_, err = store.Acquire(ctx, 1, ttl)
+		Expect(err).Should(MatchError(ErrLeaseUnavailable))
+
+		token.Release()
+		Expect(store.leases).Should(BeEmpty())
+	})
+
+	It("should expire leases of crashed holders", func() {
+		for i := 0; i < limit; i++ {
+			_, err := store.Acquire(ctx, limit, ttl)
+			Expect(err).Should(BeNil())
+		}
+
+		blocked := acquireAsync(newCC(store))
+		Consistently(blocked).ShouldNot(Receive())
+
+		step(ttl)
+		var token ConcurrencyToken
+		Eventually(blocked).Should(Receive(&token))
+		token.Release()
+	})
+
+	It("should stop renewing lost lease", func() {
+		token, err := newCC(store).Acquire(ctx)
+		Expect(err).Should(BeNil())
+
+		// Another replica takes over the lease.
+		store.mu.Lock()
+		clear(store.leases)
+		store.mu.Unlock()
+		taken, err := store.Acquire(ctx, 1, ttl)
+		Expect(err).Should(BeNil())
+
+		step(ttl / 3)
+		Eventually(lost).Should(Receive(MatchError(ErrLeaseLost)))
+
+		token.Release()
+		Expect(store.leases).Should(HaveKey(taken))
+	})
+
+	It("should retry failed renewal until lease expires", func() {
+		flaky := &flakyLeaseStore{LeaseStore: store, renewErr: make(chan error, 3)}
+		token, err := newCC(flaky).Acquire(ctx)
+		Expect(err).Should(BeNil())
+
+		unavailable := errors.New("store unavailable")
+		for i := 0; i < 3; i++ {
+			flaky.renewErr <- unavailable
+		}
+
+		step(ttl / 3)
+		step(ttl / 3)
+		Consistently(lost).ShouldNot(Receive())
+		step(ttl / 3)
+		Eventually(lost).Should(Receive(Equal(unavailable)))
+
+		token.Release()
+	})
+})
@@ -0,0 +1,121 @@
+package batcher
+
+import (
+	"context"
+	"time"
+)
+
+// BatchMetadata describes the batch that is being performed.
+type BatchMetadata interface {
+	// Items returns the metadata of each item, in the same order as the requests passed to Perform.
+	Items() []ItemMetadata
+	// Deadline returns the earliest deadline among the items, ok is false when no item has a deadline.
+	Deadline() (deadline time.Time, ok bool)
+}
+
+// ItemMetadata describes a single request of a batch.
+type ItemMetadata interface {
+	// Context returns the context the request was made with.
+	Context() context.Context
+	// Deadline returns the deadline of the request's context.
+	Deadline() (deadline time.Time, ok bool)
+	// Value returns the value associated with key in the request's context.
+	Value(key any) any
+}
+
+// batchMetadataKey is the context key of the BatchMetadata.
+type batchMetadataKey struct{}
+
+// partitionKeyKey is the context key of the partition key.
+type partitionKeyKey struct{}
+
+// batchMetadata is an implementation of the BatchMetadata interface.
+type batchMetadata struct {
+	items []ItemMetadata
+}
+
+// newBatchMetadata creates a new BatchMetadata from the contexts of the requests.
+func newBatchMetadata(contexts []context.Context) BatchMetadata {
+	items := make([]ItemMetadata, len(contexts))
+	for index, ctx := range contexts {
+		items[index] = &itemMetadata{ctx: ctx}
+	}
+
+	return &batchMetadata{
+		items: items,
+	}
+}
+
+// Items returns the metadata of each item.
+func (m *batchMetadata) Items() []ItemMetadata {
+	return m.items
+}
+
+// Deadline returns the earliest deadline among the items.
+func (m *batchMetadata) Deadline() (time.Time, bool) {
+	var earliest time.Time
+	found := false
+	for _, item := range m.items {
+		if deadline, ok := item.Deadline(); ok && (!found || deadline.Before(earliest)) {
+			earliest = deadline
+			found = true
+		}
+	}
+	return earliest, found
+}
+
+// itemMetadata is an implementation of the ItemMetadata interface.
+type itemMetadata struct {
+	ctx context.Context
+}
+
+// Context returns the context the request was made with.
+func (i *itemMetadata) Context() context.Context {
+	return i.ctx
+}
+
+// Deadline returns the deadline of the request's context.
+func (i *itemMetadata) Deadline() (time.Time, bool) {
+	return i.ctx.Deadline()
+}
+
+// Value returns the value associated with key in the request's context.
+func (i *itemMetadata) Value(key any) any {
+	return i.ctx.Value(key)
+}
+
+// ContextWithBatchMetadata returns a copy of ctx that carries the BatchMetadata.
+func ContextWithBatchMetadata(ctx context.Context, metadata BatchMetadata) context.Context {
+	return context.WithValue(ctx, batchMetadataKey{}, metadata)
+}
+
+// BatchFromContext returns the BatchMetadata of the batch being performed.
+// It returns an empty BatchMetadata if ctx was not passed to Perform by a Batcher.
+func BatchFromContext(ctx context.Context) BatchMetadata {
+	if metadata, ok := ctx.Value(batchMetadataKey{}).(BatchMetadata); ok {
+		return metadata
+	}
+	return &batchMetadata{}
+}
+
+// WithMergedDeadline returns a copy of ctx whose deadline is the earliest deadline of the batch's items.
+// If no item has a deadline it only adds cancellation.
+func WithMergedDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := BatchFromContext(ctx).Deadline(); ok {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithCancel(ctx)
+}
+
+// ContextWithPartitionKey returns a copy of ctx that carries the partition key.
+// Requests made with different partition keys are never batched together, and the context
+// passed to ConcurrencyControl.Acquire and Perform carries the partition key of the batch.
+func ContextWithPartitionKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, partitionKeyKey{}, key)
+}
+
+// PartitionKeyFromContext returns the partition key carried by ctx.
+func PartitionKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(partitionKeyKey{}).(string)
+	return key, ok
+}
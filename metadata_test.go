@@ -0,0 +1,91 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type metadataTestKey struct{}
+
+var _ = Describe("BatchMetadata", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		contexts  []context.Context
+		earliest  time.Time
+		itemCount int
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		itemCount = gofakeit.Number(3, 10)
+		contexts = make([]context.Context, itemCount)
+		earliest = time.Now().Add(time.Hour)
+
+		for i := 0; i < itemCount; i++ {
+			itemCtx := context.WithValue(context.TODO(), metadataTestKey{}, i)
+			if i%2 == 1 {
+				deadline := time.Now().Add(time.Duration(gofakeit.Number(1, 60)) * time.Minute)
+				if deadline.Before(earliest) {
+					earliest = deadline
+				}
+				var cancel context.CancelFunc
+				itemCtx, cancel = context.WithDeadline(itemCtx, deadline)
+				DeferCleanup(cancel)
+			}
+			contexts[i] = itemCtx
+		}
+
+		ctx = ContextWithBatchMetadata(ctx, newBatchMetadata(contexts))
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	It("should expose item contexts", func() {
+		items := BatchFromContext(ctx).Items()
+		Expect(items).To(HaveLen(itemCount))
+		for i, item := range items {
+			Expect(item.Context()).To(Equal(contexts[i]))
+			Expect(item.Value(metadataTestKey{})).To(Equal(i))
+
+			_, ok := item.Deadline()
+			Expect(ok).To(Equal(i%2 == 1))
+		}
+	})
+
+	It("should return earliest deadline", func() {
+		deadline, ok := BatchFromContext(ctx).Deadline()
+		Expect(ok).To(BeTrue())
+		Expect(deadline).To(Equal(earliest))
+	})
+
+	It("should merge earliest deadline into context", func() {
+		merged, cancel := WithMergedDeadline(ctx)
+		defer cancel()
+
+		deadline, ok := merged.Deadline()
+		Expect(ok).To(BeTrue())
+		Expect(deadline).To(Equal(earliest))
+	})
+
+	It("should return empty metadata if not in context", func() {
+		metadata := BatchFromContext(context.TODO())
+		Expect(metadata.Items()).To(BeEmpty())
+
+		_, ok := metadata.Deadline()
+		Expect(ok).To(BeFalse())
+
+		merged, cancel := WithMergedDeadline(context.TODO())
+		defer cancel()
+
+		_, ok = merged.Deadline()
+		Expect(ok).To(BeFalse())
+	})
+})
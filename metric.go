@@ -16,15 +16,24 @@ type MetricSet struct {
 
 	SchedulerScheduleCounter prometheus.Counter
 	SchedulerCallbackCounter prometheus.Counter
+	SchedulerWindowGauge     prometheus.Gauge
 
 	CouncurrencyControlAcquireCounter prometheus.Counter
 	ConcurrencyControlTokenCounter    prometheus.Counter
 	ConcurrencyControlErrorCounter    prometheus.Counter
 	ConcurrencyControlReleaseCounter  prometheus.Counter
+	ConcurrencyControlLimitGauge      prometheus.Gauge
+
+	CircuitBreakerStateGauge        prometheus.Gauge
+	CircuitBreakerTransitionCounter prometheus.Counter
+	CircuitBreakerRejectCounter     prometheus.Counter
 
 	DoActionCounter prometheus.Counter
 
 	BatchActionPerformCounter prometheus.Counter
+	BatchActionTimeoutCounter prometheus.Counter
+	BatchHedgeCounter         prometheus.Counter
+	BatchHedgeWinCounter      prometheus.Counter
 
 	ThunkCreatedCounter prometheus.Counter
 	ThunkSuccessCounter prometheus.Counter
@@ -92,6 +101,13 @@ func NewMetricSet(namespace, subsystem string, constLabels prometheus.Labels) *M
 			Help:        "Total number of scheduler callbacks.",
 			ConstLabels: constLabels,
 		}),
+		SchedulerWindowGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "scheduler_window_seconds",
+			Help:        "Current time window of scheduler.",
+			ConstLabels: constLabels,
+		}),
 		CouncurrencyControlAcquireCounter: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace:   namespace,
 			Subsystem:   subsystem,
@@ -120,6 +136,34 @@ func NewMetricSet(namespace, subsystem string, constLabels prometheus.Labels) *M
 			Help:        "Total number of concurrency control release.",
 			ConstLabels: constLabels,
 		}),
+		ConcurrencyControlLimitGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "concurrency_control_limit",
+			Help:        "Current concurrency limit of concurrency control.",
+			ConstLabels: constLabels,
+		}),
+		CircuitBreakerStateGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "circuit_breaker_state",
+			Help:        "Current state of circuit breaker, 0 is closed, 1 is open and 2 is half-open.",
+			ConstLabels: constLabels,
+		}),
+		CircuitBreakerTransitionCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "circuit_breaker_transition_total",
+			Help:        "Total number of circuit breaker state transition.",
+			ConstLabels: constLabels,
+		}),
+		CircuitBreakerRejectCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "circuit_breaker_reject_total",
+			Help:        "Total number of batches rejected by circuit breaker.",
+			ConstLabels: constLabels,
+		}),
 		DoActionCounter: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace:   namespace,
 			Subsystem:   subsystem,
@@ -134,6 +178,27 @@ func NewMetricSet(namespace, subsystem string, constLabels prometheus.Labels) *M
 			Help:        "Total number of batch action perform.",
 			ConstLabels: constLabels,
 		}),
+		BatchActionTimeoutCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "batch_action_timeout_total",
+			Help:        "Total number of batch action timeout.",
+			ConstLabels: constLabels,
+		}),
+		BatchHedgeCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "batch_hedge_total",
+			Help:        "Total number of batch hedge.",
+			ConstLabels: constLabels,
+		}),
+		BatchHedgeWinCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "batch_hedge_win_total",
+			Help:        "Total number of batch hedge win.",
+			ConstLabels: constLabels,
+		}),
 		ThunkCreatedCounter: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace:   namespace,
 			Subsystem:   subsystem,
@@ -169,12 +234,20 @@ func (m *MetricSet) Register(registry prometheus.Registerer) {
 		m.BatchFullCounter,
 		m.SchedulerScheduleCounter,
 		m.SchedulerCallbackCounter,
+		m.SchedulerWindowGauge,
 		m.CouncurrencyControlAcquireCounter,
 		m.ConcurrencyControlTokenCounter,
 		m.ConcurrencyControlErrorCounter,
 		m.ConcurrencyControlReleaseCounter,
+		m.ConcurrencyControlLimitGauge,
+		m.CircuitBreakerStateGauge,
+		m.CircuitBreakerTransitionCounter,
+		m.CircuitBreakerRejectCounter,
 		m.DoActionCounter,
 		m.BatchActionPerformCounter,
+		m.BatchActionTimeoutCounter,
+		m.BatchHedgeCounter,
+		m.BatchHedgeWinCounter,
 		m.ThunkCreatedCounter,
 		m.ThunkSuccessCounter,
 		m.ThunkErrorCounter,
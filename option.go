@@ -1,10 +1,17 @@
 package batcher
 
+import "time"
+
 type batcherConfig struct {
 	maxBatchSize       int
 	scheduler          Scheduler
 	concurrencyControl ConcurrencyControl
 	metrics            *MetricSet
+	orderedDispatch    bool
+	actionTimeout      time.Duration
+	hedgeDelay         time.Duration
+	maxHedges          int
+	circuitBreaker     *CircuitBreaker
 }
 
 // option is a function that configures a Batcher.
@@ -37,3 +44,40 @@ func WithMetricSet(metrics *MetricSet) option {
 		conf.metrics = metrics
 	}
 }
+
+// WithOrderedDispatch returns an option that makes batches perform one at a time in creation order.
+// A batch only starts after the previous one has finished and its thunks are filled,
+// even if the scheduler of a later batch fires first.
+func WithOrderedDispatch() option {
+	return func(conf *batcherConfig) {
+		conf.orderedDispatch = true
+	}
+}
+
+// WithActionTimeout returns an option that limits how long a batch's Perform may run.
+// Perform receives a context that expires after the timeout, and once it expires the
+// concurrency token is released and the batch's thunks are rejected with ErrActionTimeout.
+func WithActionTimeout(timeout time.Duration) option {
+	return func(conf *batcherConfig) {
+		conf.actionTimeout = timeout
+	}
+}
+
+// WithHedging returns an option that hedges slow batches.
+// If Perform has not returned after delay, a duplicate Perform is started with its own concurrency token,
+// up to maxHedges times, and the first result wins. The context of the losing attempts is cancelled.
+// Only use it for actions that are safe to perform more than once.
+func WithHedging(delay time.Duration, maxHedges int) option {
+	return func(conf *batcherConfig) {
+		conf.hedgeDelay = delay
+		conf.maxHedges = maxHedges
+	}
+}
+
+// WithCircuitBreaker returns an option that sets the circuit breaker checked before each batch is performed.
+// The same CircuitBreaker may be shared by batchers that call the same downstream.
+func WithCircuitBreaker(circuitBreaker *CircuitBreaker) option {
+	return func(conf *batcherConfig) {
+		conf.circuitBreaker = circuitBreaker
+	}
+}
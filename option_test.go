@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"context"
+	"time"
 )
 
 var _ = Describe("Option", func() {
@@ -73,4 +74,55 @@ var _ = Describe("Option", func() {
 			Expect(b.metrics).To(Equal(metrics))
 		})
 	})
+
+	Describe("can set ordered dispatch", func() {
+		BeforeEach(func() {
+			options = append(options, WithOrderedDispatch())
+		})
+
+		It("should set ordered dispatch", func() {
+			Expect(b.orderedDispatch).To(BeTrue())
+		})
+	})
+
+	Describe("can set action timeout", func() {
+		var timeout time.Duration
+		BeforeEach(func() {
+			timeout = time.Duration(gofakeit.Number(1, 10)) * time.Second
+			options = append(options, WithActionTimeout(timeout))
+		})
+
+		It("should set action timeout", func() {
+			Expect(b.actionTimeout).To(Equal(timeout))
+		})
+	})
+
+	Describe("can set hedging", func() {
+		var (
+			delay     time.Duration
+			maxHedges int
+		)
+		BeforeEach(func() {
+			delay = time.Duration(gofakeit.Number(1, 10)) * time.Millisecond
+			maxHedges = gofakeit.Number(1, 3)
+			options = append(options, WithHedging(delay, maxHedges))
+		})
+
+		It("should set hedging", func() {
+			Expect(b.hedgeDelay).To(Equal(delay))
+			Expect(b.maxHedges).To(Equal(maxHedges))
+		})
+	})
+
+	Describe("can set circuit breaker", func() {
+		var cb *CircuitBreaker
+		BeforeEach(func() {
+			cb = NewCircuitBreaker()
+			options = append(options, WithCircuitBreaker(cb))
+		})
+
+		It("should set circuit breaker", func() {
+			Expect(b.circuitBreaker).To(Equal(cb))
+		})
+	})
 })
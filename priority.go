@@ -0,0 +1,215 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// priorityKey is the context key of the priority.
+type priorityKey struct{}
+
+// ContextWithPriority returns a copy of ctx that carries the priority, higher is more important.
+// A batch has the highest priority among its requests, and the context passed to
+// ConcurrencyControl.Acquire carries it.
+func ContextWithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority carried by ctx.
+func PriorityFromContext(ctx context.Context) (int, bool) {
+	priority, ok := ctx.Value(priorityKey{}).(int)
+	return priority, ok
+}
+
+// batchPriority returns the highest priority among the contexts.
+func batchPriority(contexts []context.Context) (int, bool) {
+	highest, found := 0, false
+	for _, ctx := range contexts {
+		if priority, ok := PriorityFromContext(ctx); ok && (!found || priority > highest) {
+			highest, found = priority, true
+		}
+	}
+	return highest, found
+}
+
+// PriorityConcurrencyControl is a ConcurrencyControl whose waiters are served by priority.
+type PriorityConcurrencyControl interface {
+	ConcurrencyControl
+	// AcquirePriority acquires a concurrency token with the provided priority, higher is more important.
+	AcquirePriority(ctx context.Context, priority int) (ConcurrencyToken, error)
+}
+
+// priorityConcurrencyControl is a ConcurrencyControl that limits concurrency and hands each released token
+// to the waiter with the highest priority.
+type priorityConcurrencyControl struct {
+	mu       sync.Mutex
+	clock    clock.PassiveClock
+	aging    time.Duration
+	limit    int
+	used     int
+	waiters  []*priorityWaiter
+	released signal
+}
+
+// priorityWaiter is a caller waiting for a token.
+type priorityWaiter struct {
+	priority int
+	since    time.Time
+	ready    chan struct{}
+}
+
+// NewPriorityConcurrencyControl creates a new priorityConcurrencyControl with the provided concurrency limit.
+// Acquire reads the priority from the context with PriorityFromContext, and defaults to zero.
+func NewPriorityConcurrencyControl(concurrency int, option ...priorityConcurrencyControlOption) PriorityConcurrencyControl {
+	cc := &priorityConcurrencyControl{
+		clock: clock.RealClock{},
+		limit: concurrency,
+	}
+
+	for _, opt := range option {
+		opt(cc)
+	}
+
+	return cc
+}
+
+// Acquire acquires a concurrency token with the priority of ctx.
+func (p *priorityConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	priority, _ := PriorityFromContext(ctx)
+	return p.AcquirePriority(ctx, priority)
+}
+
+// AcquirePriority acquires a concurrency token with the provided priority.
+func (p *priorityConcurrencyControl) AcquirePriority(ctx context.Context, priority int) (ConcurrencyToken, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	p.mu.Lock()
+	if len(p.waiters) == 0 && p.used < p.limit {
+		p.used++
+		p.mu.Unlock()
+		return NewConcurrencyToken(p.release), nil
+	}
+
+	waiter := &priorityWaiter{
+		priority: priority,
+		since:    p.clock.Now(),
+		ready:    make(chan struct{}),
+	}
+	p.waiters = append(p.waiters, waiter)
+	p.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return NewConcurrencyToken(p.release), nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		select {
+		case <-waiter.ready:
+			// Handed a token while giving up, pass it on.
+			p.mu.Unlock()
+			p.release()
+		default:
+			for i, w := range p.waiters {
+				if w == waiter {
+					p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+					break
+				}
+			}
+			p.mu.Unlock()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// TryAcquire acquires a concurrency token if one is available right now.
+func (p *priorityConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.waiters) > 0 || p.used >= p.limit {
+		return nil, false
+	}
+	p.used++
+	return NewConcurrencyToken(p.release), true
+}
+
+// Available returns how many concurrency tokens could be acquired right now.
+func (p *priorityConcurrencyControl) Available() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.waiters) > 0 || p.used >= p.limit {
+		return 0
+	}
+	return p.limit - p.used
+}
+
+// Released returns a channel that is closed the next time a token is released and nobody is waiting for it.
+func (p *priorityConcurrencyControl) Released() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.released.wait()
+}
+
+// Limit returns the concurrency limit.
+func (p *priorityConcurrencyControl) Limit() int {
+	return p.limit
+}
+
+// release hands the token to the waiter with the highest priority, or gives it back if nobody waits.
+func (p *priorityConcurrencyControl) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.waiters) == 0 {
+		p.used--
+		p.released.fire()
+		return
+	}
+
+	now := p.clock.Now()
+	next := 0
+	for i := 1; i < len(p.waiters); i++ {
+		if p.effectivePriority(p.waiters[i], now) > p.effectivePriority(p.waiters[next], now) {
+			next = i
+		}
+	}
+
+	waiter := p.waiters[next]
+	p.waiters = append(p.waiters[:next], p.waiters[next+1:]...)
+	close(waiter.ready)
+}
+
+// effectivePriority returns the priority of the waiter raised by one for every aging interval it has waited.
+func (p *priorityConcurrencyControl) effectivePriority(waiter *priorityWaiter, now time.Time) int {
+	if p.aging <= 0 {
+		return waiter.priority
+	}
+	return waiter.priority + int(now.Sub(waiter.since)/p.aging)
+}
+
+// priorityConcurrencyControlOption is a function that configures a priorityConcurrencyControl.
+type priorityConcurrencyControlOption func(*priorityConcurrencyControl)
+
+// WithPriorityConcurrencyControlAging returns an option that raises the priority of a waiter by one
+// for every interval it has waited, so low priority waiters are not starved.
+func WithPriorityConcurrencyControlAging(interval time.Duration) priorityConcurrencyControlOption {
+	return func(p *priorityConcurrencyControl) {
+		p.aging = interval
+	}
+}
+
+// WithPriorityConcurrencyControlClock returns an option that sets the clock for a priorityConcurrencyControl.
+func WithPriorityConcurrencyControlClock(clock clock.PassiveClock) priorityConcurrencyControlOption {
+	return func(p *priorityConcurrencyControl) {
+		p.clock = clock
+	}
+}
@@ -0,0 +1,151 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("PriorityConcurrencyControl", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakePassiveClock
+		aging     time.Duration
+		cc        *priorityConcurrencyControl
+		token     ConcurrencyToken
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		fakeClock = clocktesting.NewFakePassiveClock(time.Now())
+		aging = time.Duration(gofakeit.Number(1, 10)) * time.Second
+		cc = NewPriorityConcurrencyControl(1,
+			WithPriorityConcurrencyControlClock(fakeClock),
+			WithPriorityConcurrencyControlAging(aging),
+		).(*priorityConcurrencyControl)
+
+		var err error
+		token, err = cc.Acquire(ctx)
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	waiting := func() int {
+		cc.mu.Lock()
+		defer cc.mu.Unlock()
+		return len(cc.waiters)
+	}
+
+	acquireAsync := func(ctx context.Context) chan ConcurrencyToken {
+		acquired := make(chan ConcurrencyToken, 1)
+		expected := waiting() + 1
+		go func() {
+			token, err := cc.Acquire(ctx)
+			if err == nil {
+				acquired <- token
+			}
+		}()
+		Eventually(waiting).Should(Equal(expected))
+		return acquired
+	}
+
+	It("should hand released token to highest priority waiter", func() {
+		low := acquireAsync(ContextWithPriority(ctx, 1))
+		high := acquireAsync(ContextWithPriority(ctx, 5))
+
+		token.Release()
+		Eventually(high).Should(Receive(&token))
+		Consistently(low).ShouldNot(Receive())
+
+		token.Release()
+		Eventually(low).Should(Receive(&token))
+		token.Release()
+		Expect(cc.used).Should(Equal(0))
+	})
+
+	It("should age waiters to avoid starvation", func() {
+		low := acquireAsync(ContextWithPriority(ctx, 1))
+		fakeClock.SetTime(fakeClock.Now().Add(5 * aging))
+		high := acquireAsync(ContextWithPriority(ctx, 5))
+
+		token.Release()
+		Eventually(low).Should(Receive(&token))
+		token.Release()
+		Eventually(high).Should(Receive(&token))
+		token.Release()
+	})
+
+	It("should accept explicit priority", func() {
+		low := acquireAsync(ctx)
+		high := make(chan ConcurrencyToken, 1)
+		go func() {
+			token, err := cc.AcquirePriority(ctx, 10)
+			if err == nil {
+				high <- token
+			}
+		}()
+		Eventually(waiting).Should(Equal(2))
+
+		token.Release()
+		Eventually(high).Should(Receive(&token))
+		token.Release()
+		Eventually(low).Should(Receive(&token))
+		token.Release()
+	})
+
+	It("should remove cancelled waiter", func() {
+		waitCtx, cancel := context.WithCancel(ctx)
+		acquireAsync(waitCtx)
+		cancel()
+		Eventually(waiting).Should(Equal(0))
+
+		token.Release()
+		Expect(cc.used).Should(Equal(0))
+	})
+
+	It("should use highest priority of batch items", func() {
+		priority, ok := batchPriority([]context.Context{
+			ContextWithPriority(ctx, 1),
+			ctx,
+			ContextWithPriority(ctx, 3),
+		})
+		Expect(ok).Should(BeTrue())
+		Expect(priority).Should(Equal(3))
+
+		_, ok = batchPriority([]context.Context{ctx})
+		Expect(ok).Should(BeFalse())
+		token.Release()
+	})
+
+	It("should try acquire and signal when released", func() {
+		_, ok := cc.TryAcquire()
+		Expect(ok).Should(BeFalse())
+		Expect(cc.Available()).Should(Equal(0))
+
+		released := cc.Released()
+		token.Release()
+		Eventually(released).Should(BeClosed())
+		Expect(cc.Available()).Should(Equal(1))
+
+		token, ok = cc.TryAcquire()
+		Expect(ok).Should(BeTrue())
+		token.Release()
+	})
+})
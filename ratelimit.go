@@ -0,0 +1,223 @@
+package batcher
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// rateLimiter is a token bucket that refills at rate tokens per second up to burst tokens.
+type rateLimiter struct {
+	mu    sync.Mutex
+	clock clock.Clock
+
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	refilled signal
+}
+
+// newRateLimiter creates a new rateLimiter with a full bucket.
+func newRateLimiter(clock clock.Clock, rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		clock:  clock,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// refill adds the tokens accumulated since the last refill, it must be called with the lock held.
+func (r *rateLimiter) refill() {
+	now := r.clock.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+}
+
+// reserve takes a token and returns how long to wait before it may be used.
+// Tokens are reserved in call order, so waiters are served first come first served.
+func (r *rateLimiter) reserve() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refill()
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.rate * float64(time.Second))
+}
+
+// cancel gives back a reserved token that will not be used.
+func (r *rateLimiter) cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refill()
+	r.tokens++
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+}
+
+// tryReserve takes a token if one is available right now.
+func (r *rateLimiter) tryReserve() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refill()
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// available returns how many whole tokens are in the bucket.
+func (r *rateLimiter) available() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refill()
+	if r.tokens < 1 {
+		return 0
+	}
+	return int(r.tokens)
+}
+
+// refilledSignal returns a channel that is closed once the bucket holds a whole token.
+func (r *rateLimiter) refilledSignal() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refill()
+	if r.refilled.ch != nil {
+		return r.refilled.ch
+	}
+
+	ch := r.refilled.wait()
+	delay := time.Duration(math.Ceil((1 - r.tokens) / r.rate * float64(time.Second)))
+	if delay <= 0 {
+		r.refilled.fire()
+		return ch
+	}
+
+	fire := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.refilled.ch == ch {
+			r.refilled.fire()
+		}
+	}
+
+	if delayed, ok := r.clock.(clock.WithDelayedExecution); ok {
+		delayed.AfterFunc(delay, fire)
+	} else {
+		timer := r.clock.NewTimer(delay)
+		go func() {
+			<-timer.C()
+			fire()
+		}()
+	}
+	return ch
+}
+
+// wait reserves a token and blocks until it may be used or ctx is done.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	delay := r.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := r.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		r.cancel()
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// rateLimitedConcurrencyControl is a ConcurrencyControl that limits how many batches start per second.
+type rateLimitedConcurrencyControl struct {
+	clock   clock.Clock
+	rate    float64
+	burst   int
+	limiter *rateLimiter
+}
+
+// NewRateLimitedConcurrencyControl creates a new rateLimitedConcurrencyControl that lets rate batches start per second,
+// with bursts of up to burst batches. It does not limit how many batches are in flight, combine it with
+// NewCompositeConcurrencyControl and NewLimitedConcurrencyControl for that. It panics if rate is not positive.
+func NewRateLimitedConcurrencyControl(rate float64, burst int, option ...rateLimitedConcurrencyControlOption) ConcurrencyControl {
+	if !(rate > 0) {
+		panic("batcher: non-positive rate for NewRateLimitedConcurrencyControl")
+	}
+
+	cc := &rateLimitedConcurrencyControl{
+		clock: clock.RealClock{},
+		rate:  rate,
+		burst: burst,
+	}
+
+	for _, opt := range option {
+		opt(cc)
+	}
+
+	cc.limiter = newRateLimiter(cc.clock, cc.rate, cc.burst)
+
+	return cc
+}
+
+// Acquire blocks until the token bucket has a token or ctx is done.
+func (r *rateLimitedConcurrencyControl) Acquire(ctx context.Context) (ConcurrencyToken, error) {
+	if err := r.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return NewConcurrencyToken(func() {}), nil
+}
+
+// TryAcquire acquires a concurrency token if the token bucket has one right now.
+func (r *rateLimitedConcurrencyControl) TryAcquire() (ConcurrencyToken, bool) {
+	if !r.limiter.tryReserve() {
+		return nil, false
+	}
+	return NewConcurrencyToken(func() {}), true
+}
+
+// Available returns how many tokens the token bucket holds right now.
+func (r *rateLimitedConcurrencyControl) Available() int {
+	return r.limiter.available()
+}
+
+// Released returns a channel that is closed once the token bucket refills a token.
+func (r *rateLimitedConcurrencyControl) Released() <-chan struct{} {
+	return r.limiter.refilledSignal()
+}
+
+// rateLimitedConcurrencyControlOption is a function that configures a rateLimitedConcurrencyControl.
+type rateLimitedConcurrencyControlOption func(*rateLimitedConcurrencyControl)
+
+// WithRateLimitedConcurrencyControlClock returns an option that sets the clock for a rateLimitedConcurrencyControl.
+func WithRateLimitedConcurrencyControlClock(clock clock.Clock) rateLimitedConcurrencyControlOption {
+	return func(r *rateLimitedConcurrencyControl) {
+		r.clock = clock
+	}
+}
@@ -0,0 +1,122 @@
+package batcher
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("RateLimitedConcurrencyControl", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakeClock
+		rate      float64
+		burst     int
+
+		cc ConcurrencyControl
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		rate = float64(gofakeit.Number(1, 10))
+		burst = gofakeit.Number(1, 5)
+		cc = NewRateLimitedConcurrencyControl(rate, burst, WithRateLimitedConcurrencyControlClock(fakeClock))
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	acquireAsync := func() chan error {
+		acquired := make(chan error, 1)
+		go func() {
+			token, err := cc.Acquire(ctx)
+			if err == nil {
+				token.Release()
+			}
+			acquired <- err
+		}()
+		return acquired
+	}
+
+	It("should reject non-positive rate", func() {
+		Expect(func() { NewRateLimitedConcurrencyControl(0, burst) }).Should(Panic())
+		Expect(func() { NewRateLimitedConcurrencyControl(-rate, burst) }).Should(Panic())
+		Expect(func() { NewRateLimitedConcurrencyControl(math.NaN(), burst) }).Should(Panic())
+	})
+
+	It("should return tokens up to burst without waiting", func() {
+		for i := 0; i < burst; i++ {
+			token, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+			Expect(token).ShouldNot(BeNil())
+		}
+	})
+
+	It("should block until a token is refilled", func() {
+		for i := 0; i < burst; i++ {
+			_, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+		}
+
+		acquired := acquireAsync()
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		Consistently(acquired).ShouldNot(Receive())
+
+		fakeClock.Step(time.Duration(float64(time.Second) / rate))
+		Eventually(acquired).Should(Receive(BeNil()))
+	})
+
+	It("should return error and give back the token if context is cancelled", func() {
+		for i := 0; i < burst; i++ {
+			_, err := cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+		}
+
+		acquired := acquireAsync()
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		cancelFunc()
+		Eventually(acquired).Should(Receive(MatchError(context.Canceled)))
+
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock.Step(time.Duration(float64(time.Second) / rate))
+		token, err := cc.Acquire(ctx)
+		Expect(err).Should(BeNil())
+		Expect(token).ShouldNot(BeNil())
+	})
+
+	It("should try acquire and signal when refilled", func() {
+		nb := cc.(NonBlockingConcurrencyControl)
+		Expect(nb.Available()).Should(Equal(burst))
+		for i := 0; i < burst; i++ {
+			_, ok := nb.TryAcquire()
+			Expect(ok).Should(BeTrue())
+		}
+
+		_, ok := nb.TryAcquire()
+		Expect(ok).Should(BeFalse())
+		Expect(nb.Available()).Should(Equal(0))
+
+		refilled := nb.Released()
+		Consistently(refilled).ShouldNot(BeClosed())
+		fakeClock.Step(time.Duration(math.Ceil(float64(time.Second) / rate)))
+		Eventually(refilled).Should(BeClosed())
+		Expect(nb.Available()).Should(Equal(1))
+	})
+})
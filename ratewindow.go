@@ -0,0 +1,107 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// RateWindowScheduler is a Scheduler that dispatches each batch when the next call allowed by a rate limit opens,
+// so a batch keeps growing while it waits for quota instead of being sent small and blocked later.
+type RateWindowScheduler struct {
+	clock    clock.Clock
+	calls    int
+	per      time.Duration
+	burst    int
+	interval time.Duration
+	limiter  *rateLimiter
+}
+
+// NewRateWindowScheduler creates a new RateWindowScheduler that dispatches at most calls batches per interval.
+// Every batch is held for one slot, per divided by calls, even when the quota went unused, then waits for its slot.
+// Slots are handed out in the order the holds end. A batch that fills up while held is dispatched right away
+// if a slot is open, and otherwise waits for its slot too. Only Shutdown, which closes batch.Dispatch(),
+// dispatches a batch before its slot. Pair it with WithMaxBatchSize to cap the items per call.
+// It panics if calls or per is not positive.
+func NewRateWindowScheduler(calls int, per time.Duration, option ...rateWindowSchedulerOption) Scheduler {
+	if calls <= 0 || per <= 0 {
+		panic("batcher: non-positive rate for NewRateWindowScheduler")
+	}
+
+	s := &RateWindowScheduler{
+		clock: clock.RealClock{},
+		calls: calls,
+		per:   per,
+		burst: 1,
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	s.interval = time.Duration(float64(s.per) / float64(s.calls))
+	s.limiter = newRateLimiter(s.clock, float64(s.calls)/s.per.Seconds(), s.burst)
+
+	return s
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (r *RateWindowScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	// The slot is only reserved once the hold ends, so a batch held past its slot can not let the next one
+	// through early.
+	hold := r.clock.NewTimer(r.interval)
+	defer hold.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-batch.Dispatch():
+		r.limiter.reserve()
+		callback.Call()
+		return
+	case <-batch.Full():
+		// A full batch can not grow any more, it only waits if no slot is open.
+		if r.limiter.tryReserve() {
+			callback.Call()
+			return
+		}
+	case <-hold.C():
+	}
+
+	delay := r.limiter.reserve()
+	if delay <= 0 {
+		callback.Call()
+		return
+	}
+
+	timer := r.clock.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		r.limiter.cancel()
+	case <-batch.Dispatch():
+		callback.Call()
+	case <-timer.C():
+		callback.Call()
+	}
+}
+
+// rateWindowSchedulerOption is a function that configures a RateWindowScheduler.
+type rateWindowSchedulerOption func(*RateWindowScheduler)
+
+// WithRateWindowSchedulerBurst returns an option that lets up to burst batches of a RateWindowScheduler
+// be dispatched back to back once their holds end, when the quota went unused. It defaults to one.
+func WithRateWindowSchedulerBurst(burst int) rateWindowSchedulerOption {
+	return func(r *RateWindowScheduler) {
+		r.burst = burst
+	}
+}
+
+// WithRateWindowSchedulerClock returns an option that sets the clock for a RateWindowScheduler.
+func WithRateWindowSchedulerClock(clock clock.Clock) rateWindowSchedulerOption {
+	return func(r *RateWindowScheduler) {
+		r.clock = clock
+	}
+}
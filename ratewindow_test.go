@@ -0,0 +1,223 @@
+package batcher
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("RateWindowScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakeClock
+		scheduler Scheduler
+		waits     atomic.Int32
+
+		fullTrigger chan struct{}
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		scheduler = NewRateWindowScheduler(6, time.Minute, WithRateWindowSchedulerClock(fakeClock))
+		waits.Store(0)
+		fullTrigger = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	// schedule schedules a batch in its own goroutine and returns its dispatch trigger,
+	// a channel closed when the callback is called and one closed when Schedule returns.
+	schedule := func(ctx context.Context) (chan struct{}, chan struct{}, chan struct{}) {
+		dispatchTrigger := make(chan struct{})
+		called := make(chan struct{})
+		returned := make(chan struct{})
+
+		mockBatch := NewMockBatch(ctrl)
+		// Schedule reads Dispatch once its timer is set, so counting the reads tells how many waits started.
+		mockBatch.EXPECT().Dispatch().DoAndReturn(func() <-chan struct{} {
+			waits.Add(1)
+			return dispatchTrigger
+		}).AnyTimes()
+		mockBatch.EXPECT().Full().Return(fullTrigger).AnyTimes()
+
+		go func() {
+			defer close(returned)
+			scheduler.Schedule(ctx, mockBatch, NewSchedulerCallback(func() {
+				close(called)
+			}))
+		}()
+		return dispatchTrigger, called, returned
+	}
+
+	// closed returns how many of the channels are closed.
+	closed := func(channels ...chan struct{}) func() int {
+		return func() int {
+			count := 0
+			for _, ch := range channels {
+				select {
+				case <-ch:
+					count++
+				default:
+				}
+			}
+			return count
+		}
+	}
+
+	// tokens returns the tokens left in the rate limiter, negative when slots are reserved ahead.
+	tokens := func() float64 {
+		limiter := scheduler.(*RateWindowScheduler).limiter
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return limiter.tokens
+	}
+
+	It("should reject non-positive rate", func() {
+		Expect(func() { NewRateWindowScheduler(0, time.Minute) }).Should(Panic())
+		Expect(func() { NewRateWindowScheduler(6, 0) }).Should(Panic())
+		Expect(func() { NewRateWindowScheduler(6, -time.Minute) }).Should(Panic())
+	})
+
+	It("should hold batch for one slot even if quota is unused", func() {
+		_, called, returned := schedule(ctx)
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(9 * time.Second)
+		Consistently(called).ShouldNot(BeClosed())
+
+		fakeClock.Step(time.Second)
+		Eventually(called).Should(BeClosed())
+		Eventually(returned).Should(BeClosed())
+	})
+
+	It("should dispatch full batch right away if quota is unused", func() {
+		_, called, returned := schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(1))
+
+		close(fullTrigger)
+		Eventually(called).Should(BeClosed())
+		Eventually(returned).Should(BeClosed())
+		Expect(tokens()).Should(BeNumerically("~", 0, 0.01))
+	})
+
+	It("should hold full batch until its slot opens", func() {
+		_, called, _ := schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(1))
+		fakeClock.Step(10 * time.Second)
+		Eventually(called).Should(BeClosed())
+
+		_, called, _ = schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(2))
+		close(fullTrigger)
+		Eventually(waits.Load).Should(BeEquivalentTo(3))
+		Consistently(called).ShouldNot(BeClosed())
+
+		fakeClock.Step(10 * time.Second)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should hold batches until their slots open", func() {
+		_, first, _ := schedule(ctx)
+		_, second, _ := schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(2))
+
+		fakeClock.Step(10 * time.Second)
+		Eventually(closed(first, second)).Should(Equal(1))
+		Eventually(waits.Load).Should(BeEquivalentTo(3))
+		Consistently(closed(first, second)).Should(Equal(1))
+
+		fakeClock.Step(10 * time.Second)
+		Eventually(closed(first, second)).Should(Equal(2))
+	})
+
+	It("should dispatch while holding if batch is dispatched and take the slot", func() {
+		_, called, _ := schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(1))
+		fakeClock.Step(10 * time.Second)
+		Eventually(called).Should(BeClosed())
+
+		dispatchTrigger, dispatched, _ := schedule(ctx)
+		_, called, _ = schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(3))
+		close(dispatchTrigger)
+		Eventually(dispatched).Should(BeClosed())
+		Expect(tokens()).Should(BeNumerically("<=", -1))
+
+		fakeClock.Step(10 * time.Second)
+		Eventually(waits.Load).Should(BeEquivalentTo(4))
+		Consistently(called).ShouldNot(BeClosed())
+		fakeClock.Step(10 * time.Second)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should dispatch before slot opens if batch is dispatched", func() {
+		firstTrigger, first, _ := schedule(ctx)
+		secondTrigger, second, _ := schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(2))
+
+		fakeClock.Step(10 * time.Second)
+		Eventually(closed(first, second)).Should(Equal(1))
+		Eventually(waits.Load).Should(BeEquivalentTo(3))
+
+		close(firstTrigger)
+		close(secondTrigger)
+		Eventually(closed(first, second)).Should(Equal(2))
+	})
+
+	It("should give slot back if context is done", func() {
+		_, called, _ := schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(1))
+		fakeClock.Step(5 * time.Second)
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		_, cancelled, returned := schedule(cancelCtx)
+		Eventually(waits.Load).Should(BeEquivalentTo(2))
+		fakeClock.Step(5 * time.Second)
+		Eventually(called).Should(BeClosed())
+
+		fakeClock.Step(5 * time.Second)
+		Eventually(waits.Load).Should(BeEquivalentTo(3))
+		Expect(tokens()).Should(BeNumerically("<", 0))
+		cancel()
+		Eventually(returned).Should(BeClosed())
+		Expect(cancelled).ShouldNot(BeClosed())
+
+		_, called, _ = schedule(ctx)
+		Eventually(waits.Load).Should(BeEquivalentTo(4))
+		fakeClock.Step(10 * time.Second)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should dispatch up to burst batches once held", func() {
+		scheduler = NewRateWindowScheduler(6, time.Minute, WithRateWindowSchedulerBurst(3), WithRateWindowSchedulerClock(fakeClock))
+		channels := make([]chan struct{}, 4)
+		for i := range channels {
+			_, channels[i], _ = schedule(ctx)
+		}
+		Eventually(waits.Load).Should(BeEquivalentTo(4))
+
+		fakeClock.Step(10 * time.Second)
+		Eventually(closed(channels...)).Should(Equal(3))
+		Eventually(waits.Load).Should(BeEquivalentTo(5))
+		Consistently(closed(channels...)).Should(Equal(3))
+
+		fakeClock.Step(10 * time.Second)
+		Eventually(closed(channels...)).Should(Equal(4))
+	})
+})
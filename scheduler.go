@@ -13,6 +13,53 @@ type Scheduler interface {
 	Schedule(ctx context.Context, batch Batch, callback SchedulerCallback)
 }
 
+// AsyncScheduler is a Scheduler that can schedule a batch without blocking, so the batcher calls it
+// without starting a goroutine for every batch.
+type AsyncScheduler interface {
+	Scheduler
+	// ScheduleAsync schedules a batch operation and returns right away. The callback is called from another goroutine
+	// when it's time to dispatch the batch, never before ScheduleAsync returns.
+	ScheduleAsync(ctx context.Context, batch Batch, callback SchedulerCallback)
+}
+
+// scheduleAndWait schedules the batch on an AsyncScheduler and blocks until it is time to dispatch it or ctx is done,
+// so an AsyncScheduler can also be used where a blocking Schedule is expected.
+func scheduleAndWait(ctx context.Context, scheduler AsyncScheduler, batch Batch, callback SchedulerCallback) {
+	fired := make(chan struct{})
+	scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+		close(fired)
+	}))
+
+	select {
+	case <-ctx.Done():
+	case <-fired:
+		callback.Call()
+	}
+}
+
+// BatchObservation describes a batch once it has been performed.
+type BatchObservation struct {
+	// Size is the number of requests performed.
+	Size int
+	// Wait is how long the batch waited between its creation and its dispatch.
+	Wait time.Duration
+	// Perform is how long the action took to perform the batch.
+	Perform time.Duration
+}
+
+// ObservingScheduler is a Scheduler that learns from the batches it scheduled.
+type ObservingScheduler interface {
+	Scheduler
+	// Observe is called every time a batch scheduled by the scheduler has been performed.
+	Observe(observation BatchObservation)
+}
+
+// windowScheduler is implemented by schedulers that can report their current time window.
+type windowScheduler interface {
+	// Window returns the current time window.
+	Window() time.Duration
+}
+
 // SchedulerCallback is an interface for callbacks that are called when it's time to dispatch a batch.
 type SchedulerCallback interface {
 	Call()
@@ -65,6 +112,134 @@ func (t *TimeWindowScheduler) Schedule(ctx context.Context, batch Batch, callbac
 	}
 }
 
+// DebounceScheduler is a Scheduler that dispatches batches once no request was appended for an idle time,
+// or after a maximum wait since the batch was created, whichever comes first.
+type DebounceScheduler struct {
+	clock   clock.Clock
+	idle    time.Duration
+	maxWait time.Duration
+}
+
+// NewDebounceScheduler creates a new DebounceScheduler with the provided idle time and maximum wait.
+// A maxWait of zero or less lets a batch wait as long as requests keep coming.
+func NewDebounceScheduler(idle time.Duration, maxWait time.Duration) Scheduler {
+	return &DebounceScheduler{
+		clock:   clock.RealClock{},
+		idle:    idle,
+		maxWait: maxWait,
+	}
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (d *DebounceScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	var deadline <-chan time.Time
+	if d.maxWait > 0 {
+		maxTimer := d.clock.NewTimer(d.maxWait)
+		defer maxTimer.Stop()
+		deadline = maxTimer.C()
+	}
+
+	for {
+		appended := batch.Appended()
+		idleTimer := d.clock.NewTimer(d.idle)
+		select {
+		case <-appended:
+			idleTimer.Stop()
+			continue
+		case <-ctx.Done():
+		case <-batch.Dispatch():
+			callback.Call()
+		case <-batch.Full():
+			callback.Call()
+		case <-deadline:
+			callback.Call()
+		case <-idleTimer.C():
+			callback.Call()
+		}
+		idleTimer.Stop()
+		return
+	}
+}
+
+// MinSizeScheduler is a Scheduler that dispatches batches once they hold a minimum number of requests,
+// or after a maximum wait since the batch was created, whichever comes first.
+type MinSizeScheduler struct {
+	clock   clock.Clock
+	minSize int
+	maxWait time.Duration
+}
+
+// NewMinSizeScheduler creates a new MinSizeScheduler with the provided minimum size and maximum wait.
+// A maxWait of zero or less lets a batch wait until it holds minSize requests.
+func NewMinSizeScheduler(minSize int, maxWait time.Duration) Scheduler {
+	return &MinSizeScheduler{
+		clock:   clock.RealClock{},
+		minSize: minSize,
+		maxWait: maxWait,
+	}
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (m *MinSizeScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	var deadline <-chan time.Time
+	if m.maxWait > 0 {
+		timer := m.clock.NewTimer(m.maxWait)
+		defer timer.Stop()
+		deadline = timer.C()
+	}
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-batch.Dispatch():
+		callback.Call()
+	case <-batch.Full():
+		callback.Call()
+	case <-batch.Reached(m.minSize):
+		callback.Call()
+	case <-deadline:
+		callback.Call()
+	}
+}
+
+// IdleCapacityScheduler is a Scheduler that dispatches batches as soon as a concurrency control has a free token,
+// and lets them grow while every token is in use, like Nagle's algorithm. It gives low latency at low load
+// and large batches at high load. The concurrency control should be the one the batcher uses.
+type IdleCapacityScheduler struct {
+	concurrencyControl NonBlockingConcurrencyControl
+}
+
+// NewIdleCapacityScheduler creates a new IdleCapacityScheduler that watches the provided concurrency control.
+func NewIdleCapacityScheduler(concurrencyControl NonBlockingConcurrencyControl) Scheduler {
+	return &IdleCapacityScheduler{
+		concurrencyControl: concurrencyControl,
+	}
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (i *IdleCapacityScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	for {
+		// Wait on the signal taken before checking, so a release in between is not missed.
+		released := i.concurrencyControl.Released()
+		if i.concurrencyControl.Available() > 0 {
+			callback.Call()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-batch.Dispatch():
+			callback.Call()
+			return
+		case <-batch.Full():
+			callback.Call()
+			return
+		case <-released:
+		}
+	}
+}
+
 // InstantScheduler is a Scheduler that dispatches batches instantly.
 type InstantScheduler struct{}
 
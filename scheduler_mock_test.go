@@ -11,6 +11,7 @@ package batcher
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -50,6 +51,137 @@ func (mr *MockSchedulerMockRecorder) Schedule(ctx, batch, callback any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockScheduler)(nil).Schedule), ctx, batch, callback)
 }
 
+// MockAsyncScheduler is a mock of AsyncScheduler interface.
+type MockAsyncScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockAsyncSchedulerMockRecorder
+}
+
+// MockAsyncSchedulerMockRecorder is the mock recorder for MockAsyncScheduler.
+type MockAsyncSchedulerMockRecorder struct {
+	mock *MockAsyncScheduler
+}
+
+// NewMockAsyncScheduler creates a new mock instance.
+func NewMockAsyncScheduler(ctrl *gomock.Controller) *MockAsyncScheduler {
+	mock := &MockAsyncScheduler{ctrl: ctrl}
+	mock.recorder = &MockAsyncSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAsyncScheduler) EXPECT() *MockAsyncSchedulerMockRecorder {
+	return m.recorder
+}
+
+// Schedule mocks base method.
+func (m *MockAsyncScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Schedule", ctx, batch, callback)
+}
+
+// Schedule indicates an expected call of Schedule.
+func (mr *MockAsyncSchedulerMockRecorder) Schedule(ctx, batch, callback any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockAsyncScheduler)(nil).Schedule), ctx, batch, callback)
+}
+
+// ScheduleAsync mocks base method.
+func (m *MockAsyncScheduler) ScheduleAsync(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ScheduleAsync", ctx, batch, callback)
+}
+
+// ScheduleAsync indicates an expected call of ScheduleAsync.
+func (mr *MockAsyncSchedulerMockRecorder) ScheduleAsync(ctx, batch, callback any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleAsync", reflect.TypeOf((*MockAsyncScheduler)(nil).ScheduleAsync), ctx, batch, callback)
+}
+
+// MockObservingScheduler is a mock of ObservingScheduler interface.
+type MockObservingScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockObservingSchedulerMockRecorder
+}
+
+// MockObservingSchedulerMockRecorder is the mock recorder for MockObservingScheduler.
+type MockObservingSchedulerMockRecorder struct {
+	mock *MockObservingScheduler
+}
+
+// NewMockObservingScheduler creates a new mock instance.
+func NewMockObservingScheduler(ctrl *gomock.Controller) *MockObservingScheduler {
+	mock := &MockObservingScheduler{ctrl: ctrl}
+	mock.recorder = &MockObservingSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockObservingScheduler) EXPECT() *MockObservingSchedulerMockRecorder {
+	return m.recorder
+}
+
+// Observe mocks base method.
+func (m *MockObservingScheduler) Observe(observation BatchObservation) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Observe", observation)
+}
+
+// Observe indicates an expected call of Observe.
+func (mr *MockObservingSchedulerMockRecorder) Observe(observation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Observe", reflect.TypeOf((*MockObservingScheduler)(nil).Observe), observation)
+}
+
+// Schedule mocks base method.
+func (m *MockObservingScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Schedule", ctx, batch, callback)
+}
+
+// Schedule indicates an expected call of Schedule.
+func (mr *MockObservingSchedulerMockRecorder) Schedule(ctx, batch, callback any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockObservingScheduler)(nil).Schedule), ctx, batch, callback)
+}
+
+// MockwindowScheduler is a mock of windowScheduler interface.
+type MockwindowScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockwindowSchedulerMockRecorder
+}
+
+// MockwindowSchedulerMockRecorder is the mock recorder for MockwindowScheduler.
+type MockwindowSchedulerMockRecorder struct {
+	mock *MockwindowScheduler
+}
+
+// NewMockwindowScheduler creates a new mock instance.
+func NewMockwindowScheduler(ctrl *gomock.Controller) *MockwindowScheduler {
+	mock := &MockwindowScheduler{ctrl: ctrl}
+	mock.recorder = &MockwindowSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockwindowScheduler) EXPECT() *MockwindowSchedulerMockRecorder {
+	return m.recorder
+}
+
+// Window mocks base method.
+func (m *MockwindowScheduler) Window() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Window")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// Window indicates an expected call of Window.
+func (mr *MockwindowSchedulerMockRecorder) Window() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Window", reflect.TypeOf((*MockwindowScheduler)(nil).Window))
+}
+
 // MockSchedulerCallback is a mock of SchedulerCallback interface.
 type MockSchedulerCallback struct {
 	ctrl     *gomock.Controller
@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 var _ = Describe("SchedulerCallback", func() {
@@ -145,3 +146,353 @@ var _ = Describe("InstantScheduler", func() {
 		scheduler.Schedule(ctx, mockBatch, mockCallback)
 	})
 })
+
+var _ = Describe("DebounceScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock    *clocktesting.FakeClock
+		mockBatch    *MockBatch
+		mockCallback *MockSchedulerCallback
+
+		appended        chan chan struct{}
+		dispatchTrigger chan struct{}
+		fullTrigger     chan struct{}
+		called          chan struct{}
+		returned        chan struct{}
+
+		scheduler *DebounceScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		scheduler = &DebounceScheduler{
+			clock:   fakeClock,
+			idle:    time.Second,
+			maxWait: 0,
+		}
+
+		appended = make(chan chan struct{}, 1)
+		dispatchTrigger = make(chan struct{})
+		fullTrigger = make(chan struct{})
+		called = make(chan struct{})
+		returned = make(chan struct{})
+
+		mockBatch = NewMockBatch(ctrl)
+		mockBatch.EXPECT().Appended().DoAndReturn(func() <-chan struct{} {
+			ch := make(chan struct{})
+			appended <- ch
+			return ch
+		}).AnyTimes()
+		mockBatch.EXPECT().Dispatch().Return(dispatchTrigger).AnyTimes()
+		mockBatch.EXPECT().Full().Return(fullTrigger).AnyTimes()
+
+		mockCallback = NewMockSchedulerCallback(ctrl)
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			defer close(returned)
+			scheduler.Schedule(ctx, mockBatch, mockCallback)
+		}()
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+		Eventually(returned).Should(BeClosed())
+	})
+
+	expectCall := func() {
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+	}
+
+	// appendRequest appends a request and waits for the idle timer to restart.
+	appendRequest := func() {
+		var ch chan struct{}
+		Eventually(appended).Should(Receive(&ch))
+		close(ch)
+		Eventually(appended).Should(Receive(&ch))
+		appended <- ch
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+	}
+
+	It("should call callback after idle time", func() {
+		expectCall()
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(time.Second)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should restart idle time on append", func() {
+		expectCall()
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(time.Second / 2)
+
+		appendRequest()
+		fakeClock.Step(time.Second / 2)
+		Consistently(called).ShouldNot(BeClosed())
+
+		fakeClock.Step(time.Second / 2)
+		Eventually(called).Should(BeClosed())
+	})
+
+	Context("with max wait", func() {
+		BeforeEach(func() {
+			scheduler.maxWait = 2 * time.Second
+		})
+
+		It("should call callback after max wait", func() {
+			expectCall()
+			for i := 0; i < 3; i++ {
+				appendRequest()
+				fakeClock.Step(time.Second / 2)
+			}
+			Consistently(called).ShouldNot(BeClosed())
+
+			appendRequest()
+			fakeClock.Step(time.Second / 2)
+			Eventually(called).Should(BeClosed())
+		})
+	})
+
+	It("should call callback if dispatch triggered", func() {
+		expectCall()
+		close(dispatchTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should call callback if full triggered", func() {
+		expectCall()
+		close(fullTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should not call callback if context is done", func() {
+		mockCallback.EXPECT().Call().Times(0)
+		cancelFunc()
+		Eventually(returned).Should(BeClosed())
+	})
+})
+
+var _ = Describe("MinSizeScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock    *clocktesting.FakeClock
+		mockBatch    *MockBatch
+		mockCallback *MockSchedulerCallback
+
+		reachedTrigger  chan struct{}
+		dispatchTrigger chan struct{}
+		fullTrigger     chan struct{}
+		called          chan struct{}
+		returned        chan struct{}
+
+		scheduler *MinSizeScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		scheduler = &MinSizeScheduler{
+			clock:   fakeClock,
+			minSize: gofakeit.Number(2, 10),
+			maxWait: time.Second,
+		}
+
+		reachedTrigger = make(chan struct{})
+		dispatchTrigger = make(chan struct{})
+		fullTrigger = make(chan struct{})
+		called = make(chan struct{})
+		returned = make(chan struct{})
+
+		mockBatch = NewMockBatch(ctrl)
+		mockBatch.EXPECT().Reached(scheduler.minSize).Return(reachedTrigger)
+		mockBatch.EXPECT().Dispatch().Return(dispatchTrigger)
+		mockBatch.EXPECT().Full().Return(fullTrigger)
+
+		mockCallback = NewMockSchedulerCallback(ctrl)
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			defer close(returned)
+			scheduler.Schedule(ctx, mockBatch, mockCallback)
+		}()
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+		Eventually(returned).Should(BeClosed())
+	})
+
+	expectCall := func() {
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+	}
+
+	It("should call callback once min size is reached", func() {
+		expectCall()
+		Consistently(called).ShouldNot(BeClosed())
+		close(reachedTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should call callback after max wait", func() {
+		expectCall()
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(time.Second)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should call callback if dispatch triggered", func() {
+		expectCall()
+		close(dispatchTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should call callback if full triggered", func() {
+		expectCall()
+		close(fullTrigger)
+		Eventually(called).Should(BeClosed())
+	})
+
+	Context("without max wait", func() {
+		BeforeEach(func() {
+			scheduler.maxWait = 0
+		})
+
+		It("should wait for min size", func() {
+			expectCall()
+			Consistently(fakeClock.HasWaiters).Should(BeFalse())
+			close(reachedTrigger)
+			Eventually(called).Should(BeClosed())
+		})
+	})
+})
+
+var _ = Describe("IdleCapacityScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		cc           NonBlockingConcurrencyControl
+		mockBatch    *MockBatch
+		mockCallback *MockSchedulerCallback
+
+		dispatchTrigger chan struct{}
+		fullTrigger     chan struct{}
+		called          chan struct{}
+		returned        chan struct{}
+
+		scheduler *IdleCapacityScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		cc = NewLimitedConcurrencyControl(1).(NonBlockingConcurrencyControl)
+		scheduler = NewIdleCapacityScheduler(cc).(*IdleCapacityScheduler)
+
+		dispatchTrigger = make(chan struct{})
+		fullTrigger = make(chan struct{})
+		called = make(chan struct{})
+		returned = make(chan struct{})
+
+		mockBatch = NewMockBatch(ctrl)
+		mockBatch.EXPECT().Dispatch().Return(dispatchTrigger).AnyTimes()
+		mockBatch.EXPECT().Full().Return(fullTrigger).AnyTimes()
+
+		mockCallback = NewMockSchedulerCallback(ctrl)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+		Eventually(returned).Should(BeClosed())
+	})
+
+	schedule := func() {
+		go func() {
+			defer close(returned)
+			scheduler.Schedule(ctx, mockBatch, mockCallback)
+		}()
+	}
+
+	expectCall := func() {
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+	}
+
+	It("should call callback instantly if capacity is free", func() {
+		expectCall()
+		schedule()
+		Eventually(called).Should(BeClosed())
+	})
+
+	Context("with every token in use", func() {
+		var token ConcurrencyToken
+
+		BeforeEach(func() {
+			var err error
+			token, err = cc.Acquire(ctx)
+			Expect(err).Should(BeNil())
+		})
+
+		It("should call callback once a token is released", func() {
+			expectCall()
+			schedule()
+			Consistently(called).ShouldNot(BeClosed())
+
+			token.Release()
+			Eventually(called).Should(BeClosed())
+		})
+
+		It("should call callback if dispatch triggered", func() {
+			expectCall()
+			schedule()
+			close(dispatchTrigger)
+			Eventually(called).Should(BeClosed())
+		})
+
+		It("should call callback if full triggered", func() {
+			expectCall()
+			schedule()
+			close(fullTrigger)
+			Eventually(called).Should(BeClosed())
+		})
+
+		It("should not call callback if context is done", func() {
+			mockCallback.EXPECT().Call().Times(0)
+			schedule()
+			cancelFunc()
+			Eventually(returned).Should(BeClosed())
+		})
+	})
+})
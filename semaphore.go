@@ -0,0 +1,169 @@
+package batcher
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// semaphore is a weighted semaphore whose waiters are served in strict FIFO order.
+// A waiter that gives up on its context is removed from the queue.
+type semaphore struct {
+	mu       sync.Mutex
+	limit    int
+	used     int
+	waiters  list.List
+	maxQueue int
+	released signal
+}
+
+// semaphoreWaiter is a caller waiting in the semaphore queue.
+type semaphoreWaiter struct {
+	n     int
+	ready chan struct{}
+}
+
+// newSemaphore creates a new semaphore with the provided limit.
+func newSemaphore(limit int) *semaphore {
+	return &semaphore{
+		limit: limit,
+	}
+}
+
+// acquire blocks until n units are available or ctx is done.
+// It fails with ErrQueueFull if the queue is bounded and full.
+func (s *semaphore) acquire(ctx context.Context, n int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	if s.waiters.Len() == 0 && s.used+n <= s.limit {
+		s.used += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	if s.maxQueue > 0 && s.waiters.Len() >= s.maxQueue {
+		s.mu.Unlock()
+		return ErrQueueFull
+	}
+
+	waiter := &semaphoreWaiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(waiter)
+	s.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		select {
+		case <-waiter.ready:
+			// Acquired while giving up, hand the units to the next waiters.
+			s.used -= n
+		default:
+			s.waiters.Remove(elem)
+		}
+		// Giving up may free capacity, for the units handed back or for callers no longer queued behind.
+		s.notifyWaiters()
+		s.released.fire()
+		return ctx.Err()
+	}
+}
+
+// tryAcquire acquires n units if they are available right now.
+func (s *semaphore) tryAcquire(n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiters.Len() == 0 && s.used+n <= s.limit {
+		s.used += n
+		return true
+	}
+	return false
+}
+
+// available returns how many units could be acquired right now.
+func (s *semaphore) available() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiters.Len() > 0 || s.used >= s.limit {
+		return 0
+	}
+	return s.limit - s.used
+}
+
+// releasedSignal returns a channel that is closed the next time units are given back.
+func (s *semaphore) releasedSignal() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.released.wait()
+}
+
+// release gives back n units.
+func (s *semaphore) release(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.used -= n
+	s.notifyWaiters()
+	s.released.fire()
+}
+
+// setLimit changes the limit, units already acquired are kept even if over the new limit.
+func (s *semaphore) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit = limit
+	s.notifyWaiters()
+	s.released.fire()
+}
+
+// notifyWaiters hands available units to the waiters at the front of the queue, it must be called with the lock held.
+func (s *semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+
+		waiter := front.Value.(*semaphoreWaiter)
+		if s.used+waiter.n > s.limit {
+			return
+		}
+
+		s.used += waiter.n
+		s.waiters.Remove(front)
+		close(waiter.ready)
+	}
+}
+
+// signal is a broadcast channel that is closed and replaced every time it fires.
+// It must be guarded by the lock of its owner.
+type signal struct {
+	ch chan struct{}
+}
+
+// wait returns a channel that is closed the next time the signal fires.
+func (s *signal) wait() <-chan struct{} {
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+// fire closes the channel returned by wait.
+func (s *signal) fire() {
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
@@ -0,0 +1,164 @@
+package batcher
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// LatencySLOScheduler is a Scheduler that picks the time window of every batch so the first request of the batch
+// is done within a target latency, while batches grow as large as the target allows.
+// It keeps running estimates of the arrival rate, and of how Perform takes longer with the batch size,
+// and leaves room for the tail of Perform. Time spent waiting for a concurrency token is not accounted for.
+type LatencySLOScheduler struct {
+	mu        sync.Mutex
+	clock     clock.Clock
+	target    time.Duration
+	minWindow time.Duration
+	maxWindow time.Duration
+	smoothing float64
+
+	window   time.Duration
+	observed bool
+	// weight and the sums are exponentially weighted over the batch sizes x and Perform seconds y.
+	weight, sumX, sumY, sumXX, sumXY float64
+	// deviation is the smoothed absolute error of the Perform estimate in seconds.
+	deviation float64
+	// rate is the smoothed arrival rate in requests per second.
+	rate float64
+}
+
+// NewLatencySLOScheduler creates a new LatencySLOScheduler with the provided target latency.
+// The window starts at half the target and stays between zero and the target,
+// unless set with WithLatencySLOSchedulerWindowBounds.
+func NewLatencySLOScheduler(target time.Duration, option ...latencySLOSchedulerOption) Scheduler {
+	s := &LatencySLOScheduler{
+		clock:     clock.RealClock{},
+		target:    target,
+		maxWindow: target,
+		smoothing: 0.2,
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	s.window = s.bound(target / 2)
+
+	return s
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (s *LatencySLOScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	window := s.Window()
+	if window <= 0 {
+		callback.Call()
+		return
+	}
+
+	timer := s.clock.NewTimer(window)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-batch.Dispatch():
+		callback.Call()
+	case <-batch.Full():
+		callback.Call()
+	case <-timer.C():
+		callback.Call()
+	}
+}
+
+// Window returns the time window of the next batch.
+func (s *LatencySLOScheduler) Window() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.window
+}
+
+// Observe updates the estimates with a performed batch and computes the next window.
+func (s *LatencySLOScheduler) Observe(observation BatchObservation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	x := float64(observation.Size)
+	y := observation.Perform.Seconds()
+	decay := 1 - s.smoothing
+
+	if s.observed {
+		fixed, perItem := s.fit()
+		s.deviation = s.deviation*decay + math.Abs(y-fixed-perItem*x)*s.smoothing
+	}
+
+	s.weight = s.weight*decay + 1
+	s.sumX = s.sumX*decay + x
+	s.sumY = s.sumY*decay + y
+	s.sumXX = s.sumXX*decay + x*x
+	s.sumXY = s.sumXY*decay + x*y
+
+	if observation.Wait > 0 && observation.Size > 0 {
+		rate := x / observation.Wait.Seconds()
+		if s.rate == 0 {
+			s.rate = rate
+		} else {
+			s.rate = s.rate*decay + rate*s.smoothing
+		}
+	}
+	s.observed = true
+
+	// The first request waits the window, then the batch of rate*window requests is performed:
+	// window + fixed + perItem*rate*window + tail <= target.
+	fixed, perItem := s.fit()
+	budget := s.target.Seconds() - fixed - 3*s.deviation
+	s.window = s.bound(time.Duration(budget / (1 + perItem*s.rate) * float64(time.Second)))
+}
+
+// fit returns the fixed and per request Perform seconds fitted over the observations, it must be called with the lock held.
+func (s *LatencySLOScheduler) fit() (float64, float64) {
+	meanX := s.sumX / s.weight
+	meanY := s.sumY / s.weight
+	variance := s.sumXX/s.weight - meanX*meanX
+	if variance < 1e-9 {
+		return meanY, 0
+	}
+
+	perItem := math.Max(0, (s.sumXY/s.weight-meanX*meanY)/variance)
+	return math.Max(0, meanY-perItem*meanX), perItem
+}
+
+// bound keeps the window within the minimum and maximum window.
+func (s *LatencySLOScheduler) bound(window time.Duration) time.Duration {
+	return max(s.minWindow, min(s.maxWindow, window))
+}
+
+// latencySLOSchedulerOption is a function that configures a LatencySLOScheduler.
+type latencySLOSchedulerOption func(*LatencySLOScheduler)
+
+// WithLatencySLOSchedulerWindowBounds returns an option that keeps the window of a LatencySLOScheduler
+// between minWindow and maxWindow.
+func WithLatencySLOSchedulerWindowBounds(minWindow, maxWindow time.Duration) latencySLOSchedulerOption {
+	return func(s *LatencySLOScheduler) {
+		s.minWindow = minWindow
+		s.maxWindow = maxWindow
+	}
+}
+
+// WithLatencySLOSchedulerSmoothing returns an option that sets the weight of every observation
+// in the estimates of a LatencySLOScheduler, between zero and one.
+func WithLatencySLOSchedulerSmoothing(smoothing float64) latencySLOSchedulerOption {
+	return func(s *LatencySLOScheduler) {
+		s.smoothing = smoothing
+	}
+}
+
+// WithLatencySLOSchedulerClock returns an option that sets the clock for a LatencySLOScheduler.
+func WithLatencySLOSchedulerClock(clock clock.Clock) latencySLOSchedulerOption {
+	return func(s *LatencySLOScheduler) {
+		s.clock = clock
+	}
+}
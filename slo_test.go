@@ -0,0 +1,123 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("LatencySLOScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakeClock
+		scheduler *LatencySLOScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		scheduler = NewLatencySLOScheduler(time.Second, WithLatencySLOSchedulerClock(fakeClock)).(*LatencySLOScheduler)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	observe := func(s *LatencySLOScheduler, times int, observations ...BatchObservation) {
+		for i := 0; i < times; i++ {
+			for _, observation := range observations {
+				s.Observe(observation)
+			}
+		}
+	}
+
+	It("should start at half the target", func() {
+		Expect(scheduler.Window()).Should(Equal(500 * time.Millisecond))
+	})
+
+	It("should leave room for perform", func() {
+		observe(scheduler, 20, BatchObservation{Size: 10, Wait: time.Second, Perform: 100 * time.Millisecond})
+		Expect(scheduler.Window()).Should(BeNumerically("~", 900*time.Millisecond, time.Millisecond))
+	})
+
+	It("should shrink window as batches take longer with size", func() {
+		observe(scheduler, 50,
+			BatchObservation{Size: 10, Wait: time.Second, Perform: 200 * time.Millisecond},
+			BatchObservation{Size: 20, Wait: time.Second, Perform: 300 * time.Millisecond},
+		)
+		slow := scheduler.Window()
+		Expect(slow).Should(BeNumerically("<", 900*time.Millisecond))
+		Expect(slow).Should(BeNumerically(">", 0))
+
+		busy := NewLatencySLOScheduler(time.Second).(*LatencySLOScheduler)
+		observe(busy, 50,
+			BatchObservation{Size: 10, Wait: 100 * time.Millisecond, Perform: 200 * time.Millisecond},
+			BatchObservation{Size: 20, Wait: 100 * time.Millisecond, Perform: 300 * time.Millisecond},
+		)
+		Expect(busy.Window()).Should(BeNumerically("<", slow))
+	})
+
+	It("should leave room for perform tail", func() {
+		steady := NewLatencySLOScheduler(time.Second).(*LatencySLOScheduler)
+		observe(steady, 50, BatchObservation{Size: 10, Wait: time.Second, Perform: 200 * time.Millisecond})
+
+		observe(scheduler, 25,
+			BatchObservation{Size: 10, Wait: time.Second, Perform: 100 * time.Millisecond},
+			BatchObservation{Size: 10, Wait: time.Second, Perform: 300 * time.Millisecond},
+		)
+		Expect(scheduler.Window()).Should(BeNumerically("<", steady.Window()))
+	})
+
+	It("should keep window within bounds", func() {
+		scheduler = NewLatencySLOScheduler(time.Second,
+			WithLatencySLOSchedulerWindowBounds(10*time.Millisecond, 800*time.Millisecond),
+		).(*LatencySLOScheduler)
+
+		observe(scheduler, 20, BatchObservation{Size: 1, Wait: time.Second, Perform: time.Millisecond})
+		Expect(scheduler.Window()).Should(Equal(800 * time.Millisecond))
+
+		observe(scheduler, 20, BatchObservation{Size: 1, Wait: time.Second, Perform: 2 * time.Second})
+		Expect(scheduler.Window()).Should(Equal(10 * time.Millisecond))
+	})
+
+	It("should call callback after window", func() {
+		mockBatch := NewMockBatch(ctrl)
+		mockBatch.EXPECT().Dispatch().Return(make(chan struct{}))
+		mockBatch.EXPECT().Full().Return(make(chan struct{}))
+
+		called := make(chan struct{})
+		mockCallback := NewMockSchedulerCallback(ctrl)
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+
+		go scheduler.Schedule(ctx, mockBatch, mockCallback)
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(scheduler.Window() - time.Millisecond)
+		Consistently(called).ShouldNot(BeClosed())
+		fakeClock.Step(time.Millisecond)
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should call callback instantly without window", func() {
+		observe(scheduler, 20, BatchObservation{Size: 1, Wait: time.Second, Perform: 2 * time.Second})
+		Expect(scheduler.Window()).Should(Equal(time.Duration(0)))
+
+		mockCallback := NewMockSchedulerCallback(ctrl)
+		mockCallback.EXPECT().Call()
+		scheduler.Schedule(ctx, NewMockBatch(ctrl), mockCallback)
+	})
+})
@@ -0,0 +1,180 @@
+package batcher
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// TimerHeapScheduler is a Scheduler that dispatches batches after a time window like TimeWindowScheduler,
+// but serves every batch from a single goroutine and timer over a heap of deadlines,
+// instead of a goroutine and a timer for every batch. The goroutine exits once no batch is pending.
+type TimerHeapScheduler struct {
+	mu         sync.Mutex
+	clock      clock.Clock
+	timeWindow time.Duration
+	entries    timerHeap
+	running    bool
+	wake       chan struct{}
+}
+
+// timerEntry is a batch waiting in a TimerHeapScheduler.
+type timerEntry struct {
+	at       time.Time
+	index    int
+	callback SchedulerCallback
+	once     sync.Once
+
+	stopReady   func() bool
+	stopContext func() bool
+}
+
+// NewTimerHeapScheduler creates a new TimerHeapScheduler with the provided time window.
+func NewTimerHeapScheduler(timeWindow time.Duration, option ...timerHeapSchedulerOption) Scheduler {
+	s := &TimerHeapScheduler{
+		clock:      clock.RealClock{},
+		timeWindow: timeWindow,
+		wake:       make(chan struct{}, 1),
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	return s
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (t *TimerHeapScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	scheduleAndWait(ctx, t, batch, callback)
+}
+
+// ScheduleAsync schedules a batch operation and returns right away.
+// The callback is called from another goroutine when it's time to dispatch the batch.
+func (t *TimerHeapScheduler) ScheduleAsync(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	entry := &timerEntry{
+		at:       t.clock.Now().Add(t.timeWindow),
+		callback: callback,
+	}
+
+	t.mu.Lock()
+	heap.Push(&t.entries, entry)
+	if !t.running {
+		t.running = true
+		go t.run()
+	} else if entry.index == 0 {
+		t.notify()
+	}
+	// The hooks may run as soon as they are set, and take the lock to remove the entry.
+	entry.stopReady = batch.AfterReady(func() {
+		t.fire(entry, true)
+	})
+	entry.stopContext = context.AfterFunc(ctx, func() {
+		t.fire(entry, false)
+	})
+	t.mu.Unlock()
+}
+
+// run waits for the earliest deadline and fires the entries that are due, until no entry is left.
+func (t *TimerHeapScheduler) run() {
+	for {
+		t.mu.Lock()
+		if len(t.entries) == 0 {
+			t.running = false
+			t.mu.Unlock()
+			return
+		}
+		delay := t.entries[0].at.Sub(t.clock.Now())
+		t.mu.Unlock()
+
+		if delay > 0 {
+			timer := t.clock.NewTimer(delay)
+			select {
+			case <-timer.C():
+			case <-t.wake:
+				timer.Stop()
+				continue
+			}
+		}
+
+		t.mu.Lock()
+		now := t.clock.Now()
+		var due []*timerEntry
+		for len(t.entries) > 0 && !t.entries[0].at.After(now) {
+			due = append(due, heap.Pop(&t.entries).(*timerEntry))
+		}
+		t.mu.Unlock()
+
+		for _, entry := range due {
+			// Dispatching performs the batch, so it must not hold up the other entries.
+			go t.fire(entry, true)
+		}
+	}
+}
+
+// fire removes the entry and calls its callback if dispatch is set, only the first time it is called.
+func (t *TimerHeapScheduler) fire(entry *timerEntry, dispatch bool) {
+	entry.once.Do(func() {
+		t.mu.Lock()
+		if entry.index >= 0 {
+			heap.Remove(&t.entries, entry.index)
+			t.notify()
+		}
+		stopReady, stopContext := entry.stopReady, entry.stopContext
+		t.mu.Unlock()
+
+		stopReady()
+		stopContext()
+		if dispatch {
+			entry.callback.Call()
+		}
+	})
+}
+
+// notify wakes up the goroutine to look at the earliest deadline again, it must be called with the lock held.
+func (t *TimerHeapScheduler) notify() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// timerHeap is a min-heap of timer entries by deadline.
+type timerHeap []*timerEntry
+
+func (h timerHeap) Len() int           { return len(h) }
+func (h timerHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x any) {
+	entry := x.(*timerEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *timerHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	entry.index = -1
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// timerHeapSchedulerOption is a function that configures a TimerHeapScheduler.
+type timerHeapSchedulerOption func(*TimerHeapScheduler)
+
+// WithTimerHeapSchedulerClock returns an option that sets the clock for a TimerHeapScheduler.
+func WithTimerHeapSchedulerClock(clock clock.Clock) timerHeapSchedulerOption {
+	return func(t *TimerHeapScheduler) {
+		t.clock = clock
+	}
+}
@@ -0,0 +1,173 @@
+package batcher
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	gomock "go.uber.org/mock/gomock"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("TimerHeapScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakeClock
+		scheduler *TimerHeapScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		scheduler = NewTimerHeapScheduler(time.Second, WithTimerHeapSchedulerClock(fakeClock)).(*TimerHeapScheduler)
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	// newBatch returns a batch and a function that makes it ready.
+	newBatch := func() (Batch, context.CancelFunc) {
+		ready, markReady := context.WithCancel(context.Background())
+		DeferCleanup(markReady)
+		mockBatch := NewMockBatch(ctrl)
+		mockBatch.EXPECT().AfterReady(gomock.Any()).DoAndReturn(func(f func()) func() bool {
+			return context.AfterFunc(ready, f)
+		}).AnyTimes()
+		return mockBatch, markReady
+	}
+
+	pending := func() int {
+		scheduler.mu.Lock()
+		defer scheduler.mu.Unlock()
+		return len(scheduler.entries)
+	}
+
+	running := func() bool {
+		scheduler.mu.Lock()
+		defer scheduler.mu.Unlock()
+		return scheduler.running
+	}
+
+	It("should call callbacks in deadline order from one goroutine", func() {
+		calls := make(chan int, 3)
+		for i := 0; i < 3; i++ {
+			i := i
+			batch, _ := newBatch()
+			scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+				calls <- i
+			}))
+			Eventually(fakeClock.HasWaiters).Should(BeTrue())
+			fakeClock.Step(100 * time.Millisecond)
+		}
+		Expect(pending()).Should(Equal(3))
+
+		Consistently(calls).ShouldNot(Receive())
+		fakeClock.Step(700 * time.Millisecond)
+		Eventually(calls).Should(Receive(Equal(0)))
+		for i := 1; i < 3; i++ {
+			Eventually(fakeClock.HasWaiters).Should(BeTrue())
+			fakeClock.Step(100 * time.Millisecond)
+			Eventually(calls).Should(Receive(Equal(i)))
+		}
+		Consistently(calls).ShouldNot(Receive())
+		Eventually(running).Should(BeFalse())
+	})
+
+	It("should call callback once batch is ready", func() {
+		called := make(chan struct{})
+		batch, markReady := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			close(called)
+		}))
+
+		markReady()
+		Eventually(called).Should(BeClosed())
+		Expect(pending()).Should(Equal(0))
+		Eventually(running).Should(BeFalse())
+	})
+
+	It("should drop batch if context is done", func() {
+		batch, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			Fail("callback should not be called")
+		}))
+
+		cancelFunc()
+		Eventually(pending).Should(Equal(0))
+		Eventually(running).Should(BeFalse())
+	})
+
+	It("should wake up for earlier deadline", func() {
+		scheduler.timeWindow = time.Hour
+		late, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, late, NewSchedulerCallback(func() {}))
+
+		scheduler.timeWindow = time.Second
+		called := make(chan struct{})
+		early, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, early, NewSchedulerCallback(func() {
+			close(called)
+		}))
+
+		Eventually(func() <-chan struct{} {
+			fakeClock.Step(100 * time.Millisecond)
+			return called
+		}).Should(BeClosed())
+		Expect(pending()).Should(Equal(1))
+	})
+
+	It("should block in schedule until dispatch", func() {
+		called := make(chan struct{})
+		mockCallback := NewMockSchedulerCallback(ctrl)
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+
+		batch, _ := newBatch()
+		go scheduler.Schedule(ctx, batch, mockCallback)
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.Step(time.Second)
+		Eventually(called).Should(BeClosed())
+	})
+})
+
+// benchmarkPartitionedDo creates a batch for every request, each in its own partition, then flushes them all.
+func benchmarkPartitionedDo(b *testing.B, scheduler Scheduler) {
+	ctx := context.Background()
+	bat := New[int, int](ctx, NewAction(func(ctx context.Context, requests []int) []Response[int] {
+		return make([]Response[int], len(requests))
+	}), WithScheduler(scheduler))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bat.Do(ContextWithPartitionKey(ctx, fmt.Sprint(i)), i)
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(runtime.NumGoroutine()), "goroutines")
+	bat.Shutdown()
+}
+
+func BenchmarkTimeWindowScheduler(b *testing.B) {
+	benchmarkPartitionedDo(b, NewTimeWindowScheduler(time.Hour))
+}
+
+func BenchmarkTimerHeapScheduler(b *testing.B) {
+	benchmarkPartitionedDo(b, NewTimerHeapScheduler(time.Hour))
+}
@@ -0,0 +1,162 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// TriggerScheduler is a Scheduler that dispatches the pending batches every time it is triggered,
+// for example on a transaction commit, a tick of an event loop or a signal. It optionally dispatches a batch
+// after a maximum wait, in case no trigger comes.
+type TriggerScheduler struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	maxWait time.Duration
+	pending map[*triggerEntry]struct{}
+}
+
+// triggerEntry is a batch waiting in a TriggerScheduler.
+type triggerEntry struct {
+	callback SchedulerCallback
+	once     sync.Once
+	stops    []func() bool
+}
+
+// NewTriggerScheduler creates a new TriggerScheduler. Batches wait for a trigger as long as it takes,
+// unless a maximum wait is set with WithTriggerSchedulerMaxWait.
+func NewTriggerScheduler(option ...triggerSchedulerOption) *TriggerScheduler {
+	s := &TriggerScheduler{
+		clock:   clock.RealClock{},
+		pending: map[*triggerEntry]struct{}{},
+	}
+
+	for _, opt := range option {
+		opt(s)
+	}
+
+	return s
+}
+
+// Schedule schedules a batch operation and calls the provided callback when it's time to dispatch the batch.
+func (t *TriggerScheduler) Schedule(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	scheduleAndWait(ctx, t, batch, callback)
+}
+
+// ScheduleAsync schedules a batch operation and returns right away, so a batch is pending as soon as it is created.
+// The callback is called from another goroutine when it's time to dispatch the batch.
+func (t *TriggerScheduler) ScheduleAsync(ctx context.Context, batch Batch, callback SchedulerCallback) {
+	entry := &triggerEntry{callback: callback}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending[entry] = struct{}{}
+	// The hooks may run as soon as they are set, and take the lock to remove the entry.
+	entry.stops = append(entry.stops,
+		batch.AfterReady(func() {
+			t.fire(entry, true)
+		}),
+		context.AfterFunc(ctx, func() {
+			t.fire(entry, false)
+		}),
+	)
+	if t.maxWait > 0 {
+		entry.stops = append(entry.stops, afterFunc(t.clock, t.maxWait, func() {
+			t.fire(entry, true)
+		}))
+	}
+}
+
+// Trigger dispatches every pending batch.
+func (t *TriggerScheduler) Trigger() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = map[*triggerEntry]struct{}{}
+	t.mu.Unlock()
+
+	for entry := range pending {
+		// Dispatching performs the batch, so it must not hold up the other batches.
+		go t.fire(entry, true)
+	}
+}
+
+// fire removes the entry and calls its callback if dispatch is set, only the first time it is called.
+func (t *TriggerScheduler) fire(entry *triggerEntry, dispatch bool) {
+	entry.once.Do(func() {
+		t.mu.Lock()
+		delete(t.pending, entry)
+		stops := entry.stops
+		t.mu.Unlock()
+
+		for _, stop := range stops {
+			stop()
+		}
+		if dispatch {
+			entry.callback.Call()
+		}
+	})
+}
+
+// TriggerFrom triggers the scheduler every time ch receives, until ctx is done or ch is closed.
+// It blocks, so run it in its own goroutine, for example on a channel passed to signal.Notify.
+func TriggerFrom[T any](ctx context.Context, scheduler *TriggerScheduler, ch <-chan T) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			scheduler.Trigger()
+		}
+	}
+}
+
+// afterFunc calls f in its own goroutine once d has passed on the clock, and returns a function that stops the call.
+func afterFunc(c clock.Clock, d time.Duration, f func()) func() bool {
+	if delayed, ok := c.(clock.WithDelayedExecution); ok {
+		return delayed.AfterFunc(d, func() {
+			go f()
+		}).Stop
+	}
+
+	timer := c.NewTimer(d)
+	stopped := make(chan struct{})
+	once := &sync.Once{}
+	go func() {
+		select {
+		case <-timer.C():
+			f()
+		case <-stopped:
+		}
+	}()
+	return func() bool {
+		active := timer.Stop()
+		once.Do(func() {
+			close(stopped)
+		})
+		return active
+	}
+}
+
+// triggerSchedulerOption is a function that configures a TriggerScheduler.
+type triggerSchedulerOption func(*TriggerScheduler)
+
+// WithTriggerSchedulerMaxWait returns an option that dispatches a batch of a TriggerScheduler after maxWait
+// if no trigger came since it was created.
+func WithTriggerSchedulerMaxWait(maxWait time.Duration) triggerSchedulerOption {
+	return func(t *TriggerScheduler) {
+		t.maxWait = maxWait
+	}
+}
+
+// WithTriggerSchedulerClock returns an option that sets the clock for a TriggerScheduler.
+func WithTriggerSchedulerClock(clock clock.Clock) triggerSchedulerOption {
+	return func(t *TriggerScheduler) {
+		t.clock = clock
+	}
+}
@@ -0,0 +1,195 @@
+package batcher
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	gomock "go.uber.org/mock/gomock"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("TriggerScheduler", func() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+
+		fakeClock *clocktesting.FakeClock
+		scheduler *TriggerScheduler
+	)
+
+	BeforeEach(func() {
+		goods := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).ShouldNot(HaveLeaked(goods))
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		scheduler = NewTriggerScheduler(WithTriggerSchedulerClock(fakeClock))
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	// newBatch returns a batch and a function that makes it ready.
+	newBatch := func() (Batch, context.CancelFunc) {
+		ready, markReady := context.WithCancel(context.Background())
+		DeferCleanup(markReady)
+		mockBatch := NewMockBatch(ctrl)
+		mockBatch.EXPECT().AfterReady(gomock.Any()).DoAndReturn(func(f func()) func() bool {
+			return context.AfterFunc(ready, f)
+		}).AnyTimes()
+		return mockBatch, markReady
+	}
+
+	pending := func() int {
+		scheduler.mu.Lock()
+		defer scheduler.mu.Unlock()
+		return len(scheduler.pending)
+	}
+
+	It("should call callbacks of pending batches on trigger", func() {
+		calls := make(chan struct{}, 3)
+		for i := 0; i < 3; i++ {
+			batch, _ := newBatch()
+			scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+				calls <- struct{}{}
+			}))
+		}
+		Expect(pending()).Should(Equal(3))
+		Consistently(calls).ShouldNot(Receive())
+
+		scheduler.Trigger()
+		for i := 0; i < 3; i++ {
+			Eventually(calls).Should(Receive())
+		}
+		Expect(pending()).Should(Equal(0))
+
+		batch, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			calls <- struct{}{}
+		}))
+		Consistently(calls).ShouldNot(Receive())
+		scheduler.Trigger()
+		Eventually(calls).Should(Receive())
+	})
+
+	It("should not wait for trigger without max wait", func() {
+		batch, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			Fail("callback should not be called")
+		}))
+
+		Expect(fakeClock.HasWaiters()).Should(BeFalse())
+		fakeClock.Step(time.Hour)
+		Consistently(pending).Should(Equal(1))
+
+		// Drop the batch before cleanup marks it ready.
+		cancelFunc()
+		Eventually(pending).Should(Equal(0))
+	})
+
+	It("should call callback after max wait", func() {
+		scheduler = NewTriggerScheduler(WithTriggerSchedulerMaxWait(time.Second), WithTriggerSchedulerClock(fakeClock))
+		called := make(chan struct{})
+		batch, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			close(called)
+		}))
+
+		Expect(fakeClock.HasWaiters()).Should(BeTrue())
+		fakeClock.Step(time.Second)
+		Eventually(called).Should(BeClosed())
+		Expect(pending()).Should(Equal(0))
+		Expect(fakeClock.HasWaiters()).Should(BeFalse())
+	})
+
+	It("should stop max wait on trigger", func() {
+		scheduler = NewTriggerScheduler(WithTriggerSchedulerMaxWait(time.Second), WithTriggerSchedulerClock(fakeClock))
+		calls := make(chan struct{}, 2)
+		batch, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			calls <- struct{}{}
+		}))
+
+		scheduler.Trigger()
+		Eventually(calls).Should(Receive())
+		Eventually(fakeClock.HasWaiters).Should(BeFalse())
+		fakeClock.Step(time.Second)
+		Consistently(calls).ShouldNot(Receive())
+	})
+
+	It("should call callback once batch is ready", func() {
+		called := make(chan struct{})
+		batch, markReady := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			close(called)
+		}))
+
+		markReady()
+		Eventually(called).Should(BeClosed())
+		Expect(pending()).Should(Equal(0))
+	})
+
+	It("should drop batch if context is done", func() {
+		batch, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			Fail("callback should not be called")
+		}))
+
+		cancelFunc()
+		Eventually(pending).Should(Equal(0))
+		scheduler.Trigger()
+	})
+
+	It("should block in schedule until trigger", func() {
+		called := make(chan struct{})
+		mockCallback := NewMockSchedulerCallback(ctrl)
+		mockCallback.EXPECT().Call().Do(func() {
+			close(called)
+		})
+
+		batch, _ := newBatch()
+		go scheduler.Schedule(ctx, batch, mockCallback)
+		Eventually(pending).Should(Equal(1))
+		scheduler.Trigger()
+		Eventually(called).Should(BeClosed())
+	})
+
+	It("should trigger from channel until it is closed", func() {
+		ch := make(chan bool)
+		done := make(chan struct{})
+		go func() {
+			TriggerFrom(ctx, scheduler, ch)
+			close(done)
+		}()
+
+		called := make(chan struct{})
+		batch, _ := newBatch()
+		scheduler.ScheduleAsync(ctx, batch, NewSchedulerCallback(func() {
+			close(called)
+		}))
+
+		ch <- true
+		Eventually(called).Should(BeClosed())
+		close(ch)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("should stop triggering from channel when context is done", func() {
+		done := make(chan struct{})
+		go func() {
+			TriggerFrom(ctx, scheduler, make(chan struct{}))
+			close(done)
+		}()
+
+		cancelFunc()
+		Eventually(done).Should(BeClosed())
+	})
+})
@@ -0,0 +1,69 @@
+package batcher
+
+import (
+	"math"
+	"time"
+)
+
+// WarmUpMode is how a warm-up ramp raises the limit from its floor to its target.
+type WarmUpMode int
+
+const (
+	// WarmUpLinear raises the limit by the same amount over every period of the ramp.
+	WarmUpLinear WarmUpMode = iota
+	// WarmUpExponential raises the limit by the same factor over every period of the ramp, like TCP slow start.
+	WarmUpExponential
+)
+
+// WarmUpConcurrencyControl is a ConcurrencyControl whose limit can be ramped up again,
+// for example after a deploy or when a circuit breaker closes.
+type WarmUpConcurrencyControl interface {
+	ConcurrencyControl
+	// WarmUp restarts the warm-up ramp from its floor.
+	WarmUp()
+}
+
+// warmUp describes a ramp of the limit from floor to a target over duration.
+type warmUp struct {
+	duration time.Duration
+	floor    int
+	mode     WarmUpMode
+}
+
+// limitAt returns the limit elapsed into the ramp towards target.
+func (w *warmUp) limitAt(elapsed time.Duration, target int) int {
+	floor := w.floorOf(target)
+	if elapsed >= w.duration {
+		return target
+	}
+
+	progress := float64(elapsed) / float64(w.duration)
+	switch w.mode {
+	case WarmUpExponential:
+		return int(float64(floor) * math.Pow(float64(target)/float64(floor), progress))
+	default:
+		return floor + int(float64(target-floor)*progress)
+	}
+}
+
+// timeOf returns how far into the ramp towards target the limit reaches limit.
+func (w *warmUp) timeOf(limit int, target int) time.Duration {
+	floor := w.floorOf(target)
+	if limit <= floor || target <= floor {
+		return 0
+	}
+
+	var progress float64
+	switch w.mode {
+	case WarmUpExponential:
+		progress = math.Log(float64(limit)/float64(floor)) / math.Log(float64(target)/float64(floor))
+	default:
+		progress = float64(limit-floor) / float64(target-floor)
+	}
+	return time.Duration(math.Ceil(progress * float64(w.duration)))
+}
+
+// floorOf returns the floor, kept between one and target.
+func (w *warmUp) floorOf(target int) int {
+	return max(1, min(w.floor, target))
+}